@@ -3,18 +3,24 @@ package main
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"os"
+	_ "time/tzdata" // routing rules resolve their timezone even without system zoneinfo
 
 	"github.com/sifan077/PowerURL/config"
 	appmodel "github.com/sifan077/PowerURL/internal/app/model"
 	apprepository "github.com/sifan077/PowerURL/internal/app/repository"
 	appserver "github.com/sifan077/PowerURL/internal/app/server"
+	appservice "github.com/sifan077/PowerURL/internal/app/service"
+	"github.com/sifan077/PowerURL/internal/infra/datacenter"
+	"github.com/sifan077/PowerURL/internal/infra/geoip"
 	"github.com/sifan077/PowerURL/internal/infra/logger"
 	infraNATS "github.com/sifan077/PowerURL/internal/infra/nats"
 	infraPostgres "github.com/sifan077/PowerURL/internal/infra/postgres"
 	infraPrometheus "github.com/sifan077/PowerURL/internal/infra/prometheus"
 	infraRedis "github.com/sifan077/PowerURL/internal/infra/redis"
+	"github.com/sifan077/PowerURL/internal/infra/threatfeed"
 	"go.uber.org/zap"
 )
 
@@ -60,9 +66,18 @@ func main() {
 	}
 	defer sqlDB.Close()
 
-	if err := infraPostgres.AutoMigrate(ctx, gormDB, &appmodel.Link{}, &appmodel.ClickEvent{}); err != nil {
+	if err := infraPostgres.AutoMigrate(ctx, gormDB, &appmodel.Link{}, &appmodel.ClickEvent{}, &appmodel.QuarantinedCode{}, &appmodel.LinkRevision{}, &appmodel.APIKey{}, &appmodel.Workspace{}, &appmodel.WorkspaceMember{}); err != nil {
 		log.Fatal("Failed to run database migrations", zap.Error(err))
 	}
+	if err := infraPostgres.EnsureSequence(ctx, gormDB, apprepository.LinkCodeSequence); err != nil {
+		log.Fatal("Failed to prepare link code sequence", zap.Error(err))
+	}
+	for _, column := range []string{"url", "code"} {
+		// Search still works without the index, just slower, so a missing extension is not fatal.
+		if err := infraPostgres.EnsureTrigramIndex(ctx, gormDB, "links", column); err != nil {
+			log.Warn("Failed to create trigram search index", zap.String("column", column), zap.Error(err))
+		}
+	}
 
 	pool, err := infraPostgres.NewPool(ctx, cfg.Postgres)
 	if err != nil {
@@ -106,16 +121,101 @@ func main() {
 
 	linkRepo := apprepository.NewLinkRepository(gormDB, redisClient)
 	clickEventRepo := apprepository.NewClickEventRepository(gormDB)
+	workspaceRepo := apprepository.NewWorkspaceRepository(gormDB)
+	workspaceService := appservice.NewWorkspaceService(workspaceRepo)
+	if err := workspaceService.EnsureDefaultWorkspace(ctx); err != nil {
+		log.Fatal("Failed to prepare default workspace", zap.Error(err))
+	}
+	apiKeyService := appservice.NewAPIKeyService(apprepository.NewAPIKeyRepository(gormDB, redisClient), workspaceRepo, log)
+
+	if cfg.Security.BootstrapAPIKey != "" {
+		if err := apiKeyService.EnsureKey(ctx, "bootstrap", cfg.Security.BootstrapAPIKey, []string{appmodel.ScopeAdmin}); err != nil {
+			log.Fatal("Failed to provision bootstrap API key", zap.Error(err))
+		}
+	} else {
+		log.Warn("No bootstrap API key configured. Set security.bootstrap_api_key or BOOTSTRAP_API_KEY to manage keys over the API")
+	}
+
+	codeGenerator, err := appservice.NewCodeGenerator(cfg.Links.CodeGen, linkRepo)
+	if err != nil {
+		log.Fatal("Failed to build link code generator", zap.Error(err))
+	}
+	codePolicy, err := appservice.NewCodePolicy(cfg.Links.Policy)
+	if err != nil {
+		log.Fatal("Failed to build link code policy", zap.Error(err))
+	}
+
+	var resolver appservice.Resolver
+	if cfg.Links.URLPolicy.ResolveDNS {
+		resolver = net.DefaultResolver
+	}
+	urlPolicy, err := appservice.NewURLPolicy(cfg.Links.URLPolicy, resolver)
+	if err != nil {
+		log.Fatal("Failed to build destination URL policy", zap.Error(err))
+	}
+	if len(cfg.Links.URLPolicy.ShortDomains) == 0 {
+		log.Warn("No short domains configured. Set links.url_policy.short_domains to the hosts this service is reached on, or links pointing back at it will redirect in a loop")
+	}
+
+	var geoDB *geoip.Database
+	if cfg.GeoIP.DatabaseFile != "" {
+		geoDB, err = geoip.Open(cfg.GeoIP, log)
+		if err != nil {
+			log.Fatal("Failed to load GeoIP database", zap.String("path", cfg.GeoIP.DatabaseFile), zap.Error(err))
+		}
+		defer geoDB.Close()
+		log.Info("Loaded GeoIP database", zap.String("path", cfg.GeoIP.DatabaseFile))
+	} else {
+		log.Info("No GeoIP database configured; geo targets are ignored")
+	}
+
+	var threatFeed appservice.ThreatFeed
+	if len(cfg.ThreatFeed.Files) > 0 {
+		if action := cfg.ThreatFeed.Action; action != "" && action != appservice.FlagActionDisable && action != appservice.FlagActionWarn {
+			log.Fatal("Invalid threat feed action", zap.String("action", action))
+		}
+		feed, err := threatfeed.Open(cfg.ThreatFeed, log)
+		if err != nil {
+			log.Fatal("Failed to load threat feed", zap.Strings("files", cfg.ThreatFeed.Files), zap.Error(err))
+		}
+		defer feed.Close()
+		domains, urls := feed.Size()
+		log.Info("Loaded threat feed", zap.Int("domains", domains), zap.Int("urls", urls))
+		threatFeed = feed
+	} else {
+		log.Info("No threat feed configured; destinations are not checked against blocklists")
+	}
+
+	var datacenters appservice.DatacenterRanges
+	if cfg.BotDetection.Enabled && len(cfg.BotDetection.DatacenterFiles) > 0 {
+		ranges, err := datacenter.Open(cfg.BotDetection, log)
+		if err != nil {
+			log.Fatal("Failed to load datacenter lists", zap.Strings("files", cfg.BotDetection.DatacenterFiles), zap.Error(err))
+		}
+		defer ranges.Close()
+		log.Info("Loaded datacenter lists", zap.Int("networks", ranges.Size()))
+		datacenters = ranges
+	}
 
 	server := appserver.New(appserver.Dependencies{
-		Logger:     log,
-		Postgres:   pool,
-		Redis:      redisClient,
-		NATS:       natsConn,
-		JetStream:  js,
-		Links:      linkRepo,
-		ClickEvents: clickEventRepo,
-		Secret:     []byte(cfg.Security.RedirectSecret),
+		Logger:        log,
+		Postgres:      pool,
+		Redis:         redisClient,
+		NATS:          natsConn,
+		JetStream:     js,
+		Links:         linkRepo,
+		ClickEvents:   clickEventRepo,
+		Secret:        []byte(cfg.Security.RedirectSecret),
+		Config:        cfg,
+		CodeGenerator: codeGenerator,
+		CodePolicy:    codePolicy,
+		URLPolicy:     urlPolicy,
+		APIKeys:       apiKeyService,
+		Workspaces:    workspaceService,
+		ClickCounter:  apprepository.NewClickCounter(gormDB, redisClient),
+		GeoIP:         geoDB,
+		ThreatFeed:    threatFeed,
+		Datacenters:   datacenters,
 	})
 
 	if err := server.Listen(":8080"); err != nil {
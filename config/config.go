@@ -27,20 +27,32 @@ type Config struct {
 
 	// Security
 	Security SecurityConfig `mapstructure:"security"`
+
+	// Links
+	Links LinksConfig `mapstructure:"links"`
+
+	// GeoIP
+	GeoIP GeoIPConfig `mapstructure:"geoip"`
+
+	// Threat feed
+	ThreatFeed ThreatFeedConfig `mapstructure:"threat_feed"`
+
+	// Bot detection
+	BotDetection BotDetectionConfig `mapstructure:"bot_detection"`
 }
 
 type PostgresConfig struct {
-	Host                string `mapstructure:"host"`
-	User                string `mapstructure:"user"`
-	Password            string `mapstructure:"password"`
-	Database            string `mapstructure:"database"`
-	Port                int    `mapstructure:"port"`
-	SSLMode             string `mapstructure:"sslmode"`
-	MaxConns            int32  `mapstructure:"max_conns"`
-	MinConns            int32  `mapstructure:"min_conns"`
-	MaxConnLifetime     string `mapstructure:"max_conn_lifetime"`
-	MaxConnIdleTime     string `mapstructure:"max_conn_idle_time"`
-	HealthCheckPeriod   string `mapstructure:"health_check_period"`
+	Host              string `mapstructure:"host"`
+	User              string `mapstructure:"user"`
+	Password          string `mapstructure:"password"`
+	Database          string `mapstructure:"database"`
+	Port              int    `mapstructure:"port"`
+	SSLMode           string `mapstructure:"sslmode"`
+	MaxConns          int32  `mapstructure:"max_conns"`
+	MinConns          int32  `mapstructure:"min_conns"`
+	MaxConnLifetime   string `mapstructure:"max_conn_lifetime"`
+	MaxConnIdleTime   string `mapstructure:"max_conn_idle_time"`
+	HealthCheckPeriod string `mapstructure:"health_check_period"`
 }
 
 type RedisConfig struct {
@@ -71,8 +83,103 @@ type GrafanaConfig struct {
 	AdminPassword string `mapstructure:"admin_password"`
 }
 
+// GeoIPConfig points at a local MaxMind-format (.mmdb) country or city database.
+type GeoIPConfig struct {
+	DatabaseFile   string `mapstructure:"database_file"`   // empty disables geo lookups
+	ReloadInterval string `mapstructure:"reload_interval"` // how often the file is checked for changes
+}
+
+// ThreatFeedConfig lists local domain/URL blocklists that destinations are checked against.
+type ThreatFeedConfig struct {
+	Files          []string `mapstructure:"files"`           // plain lists or hosts files; empty disables the feed
+	ReloadInterval string   `mapstructure:"reload_interval"` // how often the files are checked for changes
+	ScanInterval   string   `mapstructure:"scan_interval"`   // how often stored links are re-scanned
+	Action         string   `mapstructure:"action"`          // what happens to listed links: disable or warn
+}
+
+// BotDetectionConfig controls classifying click events as automated traffic.
+type BotDetectionConfig struct {
+	Enabled         bool     `mapstructure:"enabled"`
+	UserAgents      []string `mapstructure:"user_agents"`      // User-Agent substrings of bots, on top of the built-in list
+	DatacenterFiles []string `mapstructure:"datacenter_files"` // CIDR lists of hosting and cloud networks
+	ReloadInterval  string   `mapstructure:"reload_interval"`  // how often the CIDR lists are checked for changes
+	RateLimit       int      `mapstructure:"rate_limit"`       // clicks per IP and window before further ones count as bots; 0 disables
+	RateWindow      string   `mapstructure:"rate_window"`
+}
+
 type SecurityConfig struct {
-	RedirectSecret string `mapstructure:"redirect_secret"`
+	RedirectSecret     string   `mapstructure:"redirect_secret"`
+	BootstrapAPIKey    string   `mapstructure:"bootstrap_api_key"`    // admin key provisioned at startup when set
+	CORSAllowedOrigins []string `mapstructure:"cors_allowed_origins"` // "*" allows any origin; empty allows none
+}
+
+type LinksConfig struct {
+	CodeGen           CodeGenConfig     `mapstructure:"codegen"`
+	Policy            CodePolicyConfig  `mapstructure:"policy"`
+	URLPolicy         URLPolicyConfig   `mapstructure:"url_policy"`
+	QuarantinePeriod  string            `mapstructure:"quarantine_period"`  // how long purged codes stay unusable
+	IdempotencyWindow string            `mapstructure:"idempotency_window"` // how long Idempotency-Key responses are replayed
+	NotYetActiveURL   string            `mapstructure:"not_yet_active_url"` // fallback for links before starts_at; empty shows a built-in page
+	Password          PasswordConfig    `mapstructure:"password"`
+	RulesTimezone     string            `mapstructure:"rules_timezone"` // IANA zone for day/time routing conditions; empty means UTC
+	HealthCheck       HealthCheckConfig `mapstructure:"health_check"`
+	Metadata          MetadataConfig    `mapstructure:"metadata"`
+	SocialCrawlers    []string          `mapstructure:"social_crawlers"` // User-Agent substrings served preview cards; empty uses a built-in list
+}
+
+// MetadataConfig controls fetching titles, descriptions and images of destination pages.
+type MetadataConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Timeout   string `mapstructure:"timeout"`   // per fetch, redirects included
+	MaxBytes  int64  `mapstructure:"max_bytes"` // of the page that is read
+	UserAgent string `mapstructure:"user_agent"`
+}
+
+// HealthCheckConfig controls the background probing of link destinations.
+type HealthCheckConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	Interval         string `mapstructure:"interval"`          // how often every active link is probed
+	Timeout          string `mapstructure:"timeout"`           // per probe
+	Concurrency      int    `mapstructure:"concurrency"`       // probes in flight at once
+	HostInterval     string `mapstructure:"host_interval"`     // minimum gap between probes of the same host
+	FailureThreshold int    `mapstructure:"failure_threshold"` // consecutive failures before a link counts as broken
+	UserAgent        string `mapstructure:"user_agent"`
+}
+
+// PasswordConfig throttles password attempts on password-mode links.
+type PasswordConfig struct {
+	MaxAttempts   int    `mapstructure:"max_attempts"`   // failed attempts allowed per link and window
+	AttemptWindow string `mapstructure:"attempt_window"` // window after the first failed attempt
+}
+
+// URLPolicyConfig restricts which destinations links may point to.
+type URLPolicyConfig struct {
+	AllowedSchemes       []string `mapstructure:"allowed_schemes"`        // defaults to http and https
+	BlockedHosts         []string `mapstructure:"blocked_hosts"`          // also blocks their subdomains
+	ShortDomains         []string `mapstructure:"short_domains"`          // hosts this service is reached on; links to them would loop. Must be set: empty disables the check
+	AllowPrivateNetworks bool     `mapstructure:"allow_private_networks"` // permit localhost, private and reserved addresses
+	ResolveDNS           bool     `mapstructure:"resolve_dns"`            // also check the addresses host names resolve to
+	DNSTimeout           string   `mapstructure:"dns_timeout"`
+}
+
+// CodeGenConfig controls how short codes are generated when a client omits one.
+type CodeGenConfig struct {
+	Strategy     string `mapstructure:"strategy"`      // random | sequence | wordlist
+	Length       int    `mapstructure:"length"`        // code length for random/sequence
+	MaxAttempts  int    `mapstructure:"max_attempts"`  // retries on collision
+	SequenceSalt uint64 `mapstructure:"sequence_salt"` // offsets obfuscated sequence codes
+	WordlistFile string `mapstructure:"wordlist_file"` // optional newline-separated words
+	WordCount    int    `mapstructure:"word_count"`
+	Separator    string `mapstructure:"separator"`
+}
+
+// CodePolicyConfig restricts which short codes clients may claim.
+type CodePolicyConfig struct {
+	Alphabet      string   `mapstructure:"alphabet"` // allowed characters; defaults to base62 plus - and _
+	MinLength     int      `mapstructure:"min_length"`
+	MaxLength     int      `mapstructure:"max_length"`
+	Reserved      []string `mapstructure:"reserved"` // added to the route-derived reserved words
+	ProfanityFile string   `mapstructure:"profanity_file"`
 }
 
 func Load() (*Config, error) {
@@ -146,4 +253,14 @@ func bindEnvVars(v *viper.Viper) {
 
 	// Security
 	v.BindEnv("security.redirect_secret", "REDIRECT_SECRET")
+	v.BindEnv("security.bootstrap_api_key", "BOOTSTRAP_API_KEY")
+
+	// GeoIP
+	v.BindEnv("geoip.database_file", "GEOIP_DATABASE_FILE")
+
+	// Threat feed
+	v.BindEnv("threat_feed.action", "THREAT_FEED_ACTION")
+
+	// Bot detection
+	v.BindEnv("bot_detection.enabled", "BOT_DETECTION_ENABLED")
 }
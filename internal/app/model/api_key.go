@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+)
+
+// API key scopes. ScopeAdmin implies every other scope.
+const (
+	ScopeLinksRead  = "links:read"
+	ScopeLinksWrite = "links:write"
+	ScopeAdmin      = "admin"
+)
+
+// KnownScope reports whether scope is one of the defined API key scopes.
+func KnownScope(scope string) bool {
+	switch scope {
+	case ScopeLinksRead, ScopeLinksWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIKey is a credential for the management API. Only a SHA-256 hash of the
+// secret is stored; the plaintext is shown once at creation time. A key acts in
+// WorkspaceID unless a request selects another workspace its OwnerID belongs to.
+type APIKey struct {
+	ID          string     `json:"id" gorm:"primaryKey;size:36"`
+	Name        string     `json:"name" gorm:"size:128;not null"`
+	WorkspaceID string     `json:"workspace_id" gorm:"size:36;not null;default:default;index"`
+	OwnerID     string     `json:"owner_id" gorm:"size:128;not null;default:''"`
+	Prefix      string     `json:"prefix" gorm:"size:16;not null"`
+	KeyHash     string     `json:"key_hash" gorm:"size:64;not null;uniqueIndex"`
+	Scopes      []string   `json:"scopes" gorm:"serializer:json;type:jsonb;not null"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// HasScope reports whether the key grants scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
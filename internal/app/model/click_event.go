@@ -5,11 +5,16 @@ import "time"
 // ClickEvent represents a click event on a short link
 type ClickEvent struct {
 	ID        string    `json:"id" gorm:"primaryKey;size:36"`
-	LinkCode  string    `json:"link_code" gorm:"size:32;not null;index"`
+	LinkCode  string    `json:"link_code" gorm:"size:32;not null;index;index:idx_click_events_link_time,priority:1"`
 	IP        string    `json:"ip" gorm:"size:64;not null"`
 	UserAgent string    `json:"user_agent" gorm:"type:text"`
 	Status    string    `json:"status" gorm:"size:16;not null;default:success;index"`
-	Timestamp time.Time `json:"timestamp" gorm:"not null;index"`
+	RuleID    string    `json:"rule_id,omitempty" gorm:"size:36"`       // routing rule that chose the destination, if any
+	Country   string    `json:"country,omitempty" gorm:"size:2;index"`  // ISO country resolved from IP, if known
+	Variant   string    `json:"variant,omitempty" gorm:"size:32;index"` // A/B variant the visitor was assigned, if any
+	IsBot     bool      `json:"is_bot" gorm:"not null;default:false;index"`
+	BotReason string    `json:"bot_reason,omitempty" gorm:"size:255;not null;default:''"` // why the click was classified as automated
+	Timestamp time.Time `json:"timestamp" gorm:"not null;index;index:idx_click_events_link_time,priority:2"`
 }
 
 const (
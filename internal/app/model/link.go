@@ -1,15 +1,101 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Link describes the core short-link entity stored in Postgres.
 type Link struct {
-	Code         string     `db:"code" gorm:"primaryKey;size:32"`
-	URL          string     `db:"url" gorm:"type:text;not null"`
-	Mode         string     `db:"mode" gorm:"size:16;not null;default:direct"`
-	TimerSeconds int        `db:"timer_seconds" gorm:"not null;default:0"`
-	Disabled     bool       `db:"disabled" gorm:"not null;default:false"`
-	ExpiresAt    *time.Time `db:"expires_at" gorm:"index"`
-	CreatedAt    time.Time  `db:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt    time.Time  `db:"updated_at" gorm:"autoUpdateTime"`
+	Code           string         `db:"code" gorm:"primaryKey;size:32;index:idx_links_created_code,priority:2"`
+	WorkspaceID    string         `db:"workspace_id" gorm:"size:36;not null;default:default;index"`
+	OwnerID        string         `db:"owner_id" gorm:"size:128;not null;default:''"`
+	URL            string         `db:"url" gorm:"type:text;not null"`
+	Mode           string         `db:"mode" gorm:"size:16;not null;default:direct"`
+	TimerSeconds   int            `db:"timer_seconds" gorm:"not null;default:0"`
+	Disabled       bool           `db:"disabled" gorm:"not null;default:false"`
+	PasswordHash   string         `db:"password_hash" gorm:"size:60;not null;default:''"`
+	StartsAt       *time.Time     `db:"starts_at" gorm:"index"`
+	ExpiresAt      *time.Time     `db:"expires_at" gorm:"index"`
+	MaxClicks      *int           `db:"max_clicks"`
+	Rules          LinkRules      `db:"rules" gorm:"type:jsonb;not null;default:'[]'"`
+	GeoTargets     GeoTargets     `db:"geo_targets" gorm:"type:jsonb;not null;default:'{}'"`
+	Variants       LinkVariants   `db:"variants" gorm:"type:jsonb;not null;default:'[]'"`
+	QueryPolicy    string         `db:"query_policy" gorm:"size:16;not null;default:ignore"`
+	ForwardPath    bool           `db:"forward_path" gorm:"not null;default:false"`      // prefix link: /code/a/b goes to destination/a/b
+	Social         LinkSocial     `gorm:"embedded;embeddedPrefix:social_"`               // shown to social crawlers
+	FlagReason     string         `db:"flag_reason" gorm:"size:255;not null;default:''"` // why the threat scanner flagged the link
+	FlagAction     string         `db:"flag_action" gorm:"size:16;not null;default:''"`  // disable or warn; empty when not flagged
+	FlaggedAt      *time.Time     `db:"flagged_at" gorm:"index"`
+	FlagReviewedAt *time.Time     `db:"flag_reviewed_at"` // a reviewer cleared the flag; unchanged links are not flagged again
+	Health         LinkHealth     `gorm:"embedded;embeddedPrefix:health_"`
+	Preview        LinkPreview    `gorm:"embedded;embeddedPrefix:preview_"`
+	ClickCount     int64          `db:"click_count" gorm:"not null;default:0"`
+	Version        int            `db:"version" gorm:"not null;default:1"`
+	CreatedAt      time.Time      `db:"created_at" gorm:"autoCreateTime;index:idx_links_created_code,priority:1"`
+	UpdatedAt      time.Time      `db:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `db:"deleted_at" gorm:"index"`
+}
+
+// InWorkspace reports whether the link belongs to workspaceID. Links created
+// before workspaces existed belong to the default workspace.
+func (l *Link) InWorkspace(workspaceID string) bool {
+	id := l.WorkspaceID
+	if id == "" {
+		id = DefaultWorkspaceID
+	}
+	return id == workspaceID
+}
+
+// NotYetActive reports whether the link is scheduled to start after now.
+func (l *Link) NotYetActive(now time.Time) bool {
+	return l.StartsAt != nil && now.Before(*l.StartsAt)
+}
+
+// Flagged reports whether the link is flagged as pointing to a malicious destination.
+func (l *Link) Flagged() bool {
+	return l.FlaggedAt != nil
+}
+
+// Route records which of a link's destinations a visit was sent to. Empty fields
+// did not apply.
+type Route struct {
+	RuleID    string // matched routing rule
+	GeoKey    string // matched geo target, only consulted without a rule
+	VariantID string // assigned A/B variant, only consulted without a rule or geo target
+}
+
+// Destination returns the target of the route's rule, else of its geo target, else
+// of its variant, falling back to URL when none is set or exists any more.
+func (l *Link) Destination(route Route) string {
+	if route.RuleID != "" {
+		if rule := l.Rules.Rule(route.RuleID); rule != nil {
+			return rule.Destination
+		}
+	}
+	if route.GeoKey != "" {
+		if target, ok := l.GeoTargets[route.GeoKey]; ok {
+			return target
+		}
+	}
+	if route.VariantID != "" {
+		if variant := l.Variants.Variant(route.VariantID); variant != nil {
+			return variant.Destination
+		}
+	}
+	return l.URL
+}
+
+// ClickCapped reports whether the link has no successful redirects left at count.
+func (l *Link) ClickCapped(count int64) bool {
+	return l.MaxClicks != nil && *l.MaxClicks > 0 && count >= int64(*l.MaxClicks)
+}
+
+// QuarantinedCode keeps a purged short code out of circulation until Until,
+// so it cannot be silently reassigned to a different destination.
+type QuarantinedCode struct {
+	Code      string    `db:"code" gorm:"primaryKey;size:32"`
+	Until     time.Time `db:"until" gorm:"not null;index"`
+	CreatedAt time.Time `db:"created_at" gorm:"autoCreateTime"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// LinkBrokenSubject is the NATS subject LinkBrokenEvents are published on.
+const LinkBrokenSubject = "links.broken"
+
+// LinkHealth is the outcome of the latest probes of a link's destination.
+type LinkHealth struct {
+	Status    int        `db:"health_status" gorm:"not null;default:0"`     // HTTP status of the last probe; 0 when no response arrived
+	LatencyMs int        `db:"health_latency_ms" gorm:"not null;default:0"` // duration of the last probe
+	Failures  int        `db:"health_failures" gorm:"not null;default:0"`   // consecutive failed probes
+	Broken    bool       `db:"health_broken" gorm:"not null;default:false;index"`
+	Error     string     `db:"health_error" gorm:"size:255;not null;default:''"` // why the last probe failed
+	CheckedAt *time.Time `db:"health_checked_at"`
+}
+
+// LinkBrokenEvent announces that a link's destination just started failing probes.
+type LinkBrokenEvent struct {
+	Code        string    `json:"code"`
+	WorkspaceID string    `json:"workspace_id"`
+	URL         string    `json:"url"`
+	Status      int       `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	Failures    int       `json:"failures"`
+	Timestamp   time.Time `json:"timestamp"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Link metadata fetch queue.
+const (
+	MetadataStreamName    = "LINK_METADATA"
+	MetadataStreamSubject = "links.metadata"
+	MetadataConsumerName  = "metadata-fetcher"
+)
+
+// LinkPreview describes the page a link's URL points to, taken from its title,
+// OpenGraph tags and icons.
+type LinkPreview struct {
+	URL         string     `db:"preview_url" gorm:"type:text;not null;default:''"` // the destination the preview was fetched for
+	Title       string     `db:"preview_title" gorm:"size:300;not null;default:''"`
+	Description string     `db:"preview_description" gorm:"size:1000;not null;default:''"`
+	ImageURL    string     `db:"preview_image_url" gorm:"type:text;not null;default:''"` // og:image
+	FaviconURL  string     `db:"preview_favicon_url" gorm:"type:text;not null;default:''"`
+	FetchedAt   *time.Time `db:"preview_fetched_at"`
+}
+
+// Describes reports whether the preview was fetched for url, i.e. is not stale.
+func (p LinkPreview) Describes(url string) bool {
+	return p.FetchedAt != nil && p.URL == url
+}
+
+// MetadataFetchRequest asks for the preview of a link's URL to be fetched.
+type MetadataFetchRequest struct {
+	Code string `json:"code"`
+	URL  string `json:"url"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// LinkRevision is a snapshot of a link's mutable fields, written on every change.
+type LinkRevision struct {
+	ID           uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	LinkCode     string       `json:"link_code" gorm:"size:32;not null;index"`
+	Action       string       `json:"action" gorm:"size:16;not null"`
+	RevertedFrom *uint        `json:"reverted_from,omitempty"`
+	URL          string       `json:"url" gorm:"type:text;not null"`
+	Mode         string       `json:"mode" gorm:"size:16;not null"`
+	TimerSeconds int          `json:"timer_seconds" gorm:"not null;default:0"`
+	Disabled     bool         `json:"disabled" gorm:"not null;default:false"`
+	PasswordHash string       `json:"-" gorm:"size:60;not null;default:''"`
+	StartsAt     *time.Time   `json:"starts_at"`
+	ExpiresAt    *time.Time   `json:"expires_at"`
+	MaxClicks    *int         `json:"max_clicks"`
+	Rules        LinkRules    `json:"rules" gorm:"type:jsonb;not null;default:'[]'"`
+	GeoTargets   GeoTargets   `json:"geo_targets" gorm:"type:jsonb;not null;default:'{}'"`
+	Variants     LinkVariants `json:"variants" gorm:"type:jsonb;not null;default:'[]'"`
+	QueryPolicy  string       `json:"query_policy" gorm:"size:16;not null;default:ignore"`
+	ForwardPath  bool         `json:"forward_path" gorm:"not null;default:false"`
+	Social       LinkSocial   `json:"social" gorm:"embedded;embeddedPrefix:social_"`
+	CreatedAt    time.Time    `json:"created_at" gorm:"autoCreateTime"`
+}
+
+const (
+	RevisionActionCreate = "create"
+	RevisionActionUpdate = "update"
+	RevisionActionRevert = "revert"
+)
+
+// Restore copies the snapshotted fields back onto link.
+func (r *LinkRevision) Restore(link *Link) {
+	link.URL = r.URL
+	link.Mode = r.Mode
+	link.TimerSeconds = r.TimerSeconds
+	link.Disabled = r.Disabled
+	link.PasswordHash = r.PasswordHash
+	link.StartsAt = r.StartsAt
+	link.ExpiresAt = r.ExpiresAt
+	link.MaxClicks = r.MaxClicks
+	link.Rules = r.Rules
+	link.GeoTargets = r.GeoTargets
+	link.Variants = r.Variants
+	link.QueryPolicy = r.QueryPolicy
+	link.ForwardPath = r.ForwardPath
+	link.Social = r.Social
+}
+
+// NewLinkRevision snapshots the current state of link.
+func NewLinkRevision(link *Link, action string) *LinkRevision {
+	return &LinkRevision{
+		LinkCode:     link.Code,
+		Action:       action,
+		URL:          link.URL,
+		Mode:         link.Mode,
+		TimerSeconds: link.TimerSeconds,
+		Disabled:     link.Disabled,
+		PasswordHash: link.PasswordHash,
+		StartsAt:     link.StartsAt,
+		ExpiresAt:    link.ExpiresAt,
+		MaxClicks:    link.MaxClicks,
+		Rules:        link.Rules,
+		GeoTargets:   link.GeoTargets,
+		Variants:     link.Variants,
+		QueryPolicy:  link.QueryPolicy,
+		ForwardPath:  link.ForwardPath,
+		Social:       link.Social,
+	}
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// LinkRule sends visitors matching Conditions to Destination instead of the link's URL.
+// A link's rules are evaluated in order and the first match wins.
+type LinkRule struct {
+	ID          string         `json:"id"`
+	Conditions  RuleConditions `json:"conditions"`
+	Destination string         `json:"destination"`
+}
+
+// RuleConditions are ANDed together; unset conditions match every request and a
+// list matches when any of its entries does.
+type RuleConditions struct {
+	Platforms     []string          `json:"platforms,omitempty"`      // ios, android, desktop
+	Languages     []string          `json:"languages,omitempty"`      // Accept-Language tags, e.g. "en" or "pt-BR"
+	Query         map[string]string `json:"query,omitempty"`          // parameter -> value, "*" for any value
+	ReferrerHosts []string          `json:"referrer_hosts,omitempty"` // host or any of its subdomains
+	Days          []string          `json:"days,omitempty"`           // mon, tue, wed, thu, fri, sat, sun
+	TimeFrom      string            `json:"time_from,omitempty"`      // HH:MM, inclusive
+	TimeTo        string            `json:"time_to,omitempty"`        // HH:MM, exclusive; may wrap past midnight
+}
+
+// LinkRules is an ordered rule list stored as a jsonb column.
+type LinkRules []LinkRule
+
+// Value implements driver.Valuer.
+func (r LinkRules) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (r *LinkRules) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("scan link rules: unsupported type %T", value)
+	}
+	return json.Unmarshal(data, r)
+}
+
+// Rule returns the rule with the given id, or nil.
+func (r LinkRules) Rule(id string) *LinkRule {
+	for i := range r {
+		if r[i].ID == id {
+			return &r[i]
+		}
+	}
+	return nil
+}
+
+// GeoTargets maps visitor locations to destinations. Keys are ISO 3166-1 country
+// codes ("DE") or ISO 3166-2 region codes ("US-CA"); a region beats its country.
+type GeoTargets map[string]string
+
+// Match returns the key of the most specific target for country and region, or "".
+func (g GeoTargets) Match(country, region string) string {
+	if region != "" {
+		if _, ok := g[region]; ok {
+			return region
+		}
+	}
+	if country != "" {
+		if _, ok := g[country]; ok {
+			return country
+		}
+	}
+	return ""
+}
+
+// Value implements driver.Valuer.
+func (g GeoTargets) Value() (driver.Value, error) {
+	if g == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (g *GeoTargets) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*g = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("scan geo targets: unsupported type %T", value)
+	}
+	return json.Unmarshal(data, g)
+}
@@ -0,0 +1,9 @@
+package model
+
+// LinkSocial overrides what social crawlers show when the link is shared. Empty
+// fields fall back to the fetched preview of the destination.
+type LinkSocial struct {
+	Title       string `db:"social_title" json:"title,omitempty" gorm:"size:300;not null;default:''"`
+	Description string `db:"social_description" json:"description,omitempty" gorm:"size:1000;not null;default:''"`
+	ImageURL    string `db:"social_image_url" json:"image_url,omitempty" gorm:"type:text;not null;default:''"` // og:image
+}
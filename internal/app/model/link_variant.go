@@ -0,0 +1,83 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// LinkVariant is one destination of an A/B test. Visitors are split between a
+// link's variants in proportion to their weights.
+type LinkVariant struct {
+	ID          string `json:"id"` // short label reported in click stats, e.g. "control"
+	Destination string `json:"destination"`
+	Weight      int    `json:"weight"` // 0 pauses the variant
+}
+
+// LinkVariants is a link's A/B variant list stored as a jsonb column.
+type LinkVariants []LinkVariant
+
+// Value implements driver.Valuer.
+func (v LinkVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (v *LinkVariants) Scan(value interface{}) error {
+	var data []byte
+	switch val := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	default:
+		return fmt.Errorf("scan link variants: unsupported type %T", value)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Variant returns the variant with the given id, or nil.
+func (v LinkVariants) Variant(id string) *LinkVariant {
+	for i := range v {
+		if v[i].ID == id {
+			return &v[i]
+		}
+	}
+	return nil
+}
+
+// Pick returns the variant that roll, a number in [0, total weight), falls on,
+// or nil when no variant has weight.
+func (v LinkVariants) Pick(roll int) *LinkVariant {
+	for i := range v {
+		if v[i].Weight <= 0 {
+			continue
+		}
+		if roll < v[i].Weight {
+			return &v[i]
+		}
+		roll -= v[i].Weight
+	}
+	return nil
+}
+
+// TotalWeight sums the weights of all variants.
+func (v LinkVariants) TotalWeight() int {
+	total := 0
+	for _, variant := range v {
+		if variant.Weight > 0 {
+			total += variant.Weight
+		}
+	}
+	return total
+}
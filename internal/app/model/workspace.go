@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// DefaultWorkspaceID owns links and keys that were not assigned a workspace.
+const DefaultWorkspaceID = "default"
+
+// Workspace member roles.
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleMember = "member"
+)
+
+// Workspace groups links so that teams only see and edit their own.
+type Workspace struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:36"`
+	Name      string    `json:"name" gorm:"size:128;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// WorkspaceMember grants a member (identified by an opaque ID such as an email)
+// access to a workspace.
+type WorkspaceMember struct {
+	WorkspaceID string    `json:"workspace_id" gorm:"primaryKey;size:36"`
+	MemberID    string    `json:"member_id" gorm:"primaryKey;size:128;index"`
+	Role        string    `json:"role" gorm:"size:16;not null;default:member"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ValidWorkspaceRole reports whether role is a known member role.
+func ValidWorkspaceRole(role string) bool {
+	return role == WorkspaceRoleOwner || role == WorkspaceRoleMember
+}
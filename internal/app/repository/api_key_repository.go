@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound signals that no API key matches the lookup.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const (
+	apiKeyCachePrefix  = "apikey:"
+	apiKeyUsedPrefix   = "apikey:used:"
+	apiKeyCacheTTL     = 5 * time.Minute
+	apiKeyCacheNullTTL = 1 * time.Minute
+	// apiKeyUsedInterval bounds how often last_used_at is written per key.
+	apiKeyUsedInterval = 1 * time.Minute
+)
+
+// APIKeyRepository defines the data access contract for API keys.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	List(ctx context.Context, limit, offset int) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	MarkUsed(ctx context.Context, id string, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+// NewAPIKeyRepository returns a GORM-backed APIKeyRepository with Redis caching.
+func NewAPIKeyRepository(db *gorm.DB, redis *redis.Client) APIKeyRepository {
+	return &apiKeyRepository{
+		db:    db,
+		redis: redis,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return err
+	}
+	if r.redis != nil {
+		r.redis.Del(ctx, apiKeyCachePrefix+key.KeyHash)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	cacheKey := apiKeyCachePrefix + hash
+
+	if r.redis != nil {
+		cached, err := r.redis.Get(ctx, cacheKey).Result()
+		if err == nil {
+			if cached == cacheNullValue {
+				return nil, ErrAPIKeyNotFound
+			}
+			var key model.APIKey
+			if err := json.Unmarshal([]byte(cached), &key); err == nil {
+				return &key, nil
+			}
+		}
+	}
+
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if r.redis != nil {
+				r.redis.Set(ctx, cacheKey, cacheNullValue, apiKeyCacheNullTTL)
+			}
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	if r.redis != nil {
+		data, err := json.Marshal(key)
+		if err == nil {
+			r.redis.Set(ctx, cacheKey, data, apiKeyCacheTTL)
+		}
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context, limit, offset int) ([]model.APIKey, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var result []model.APIKey
+	if err := r.db.WithContext(ctx).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	if key.RevokedAt == nil {
+		if err := r.db.WithContext(ctx).
+			Model(&model.APIKey{}).
+			Where("id = ?", id).
+			Update("revoked_at", at).Error; err != nil {
+			return err
+		}
+	}
+
+	if r.redis != nil {
+		r.redis.Del(ctx, apiKeyCachePrefix+key.KeyHash)
+	}
+	return nil
+}
+
+// MarkUsed records the last use of a key. Writes are throttled through Redis so a
+// busy key touches Postgres at most once per apiKeyUsedInterval.
+func (r *apiKeyRepository) MarkUsed(ctx context.Context, id string, at time.Time) error {
+	if r.redis != nil {
+		acquired, err := r.redis.SetNX(ctx, apiKeyUsedPrefix+id, at.Unix(), apiKeyUsedInterval).Result()
+		if err == nil && !acquired {
+			return nil
+		}
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
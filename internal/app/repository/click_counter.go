@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"gorm.io/gorm"
+)
+
+const (
+	clickCountKeyPrefix = "clicks:"
+	// clickCountDirtyKey collects codes whose Redis counter has not been written back yet.
+	clickCountDirtyKey = "clicks:dirty"
+	// clickClaimKeyPrefix marks clicks that were already counted.
+	clickClaimKeyPrefix = "clickclaim:"
+)
+
+// ClickCounter keeps live per-link counts of successful redirects. Redis is the
+// source of truth while a counter is loaded; Flush writes counters back to links.click_count.
+type ClickCounter interface {
+	// Current returns the live count for code.
+	Current(ctx context.Context, code string) (int64, error)
+	// Claim counts a successful redirect unless it would exceed limit (0 means
+	// unlimited). It returns the new count and whether the redirect may proceed.
+	Claim(ctx context.Context, code string, limit int) (int64, bool, error)
+	// ClaimOnce is Claim for the click clickID, which may be replayed for up to ttl:
+	// a click that was counted is allowed again without counting it twice.
+	ClaimOnce(ctx context.Context, code, clickID string, limit int, ttl time.Duration) (int64, bool, error)
+	// Reset sets the count for code back to zero in Redis and Postgres.
+	Reset(ctx context.Context, code string) error
+	// Forget drops the Redis counter for code, e.g. after the link was purged.
+	Forget(ctx context.Context, code string) error
+	// Flush writes up to batch dirty counters back to Postgres and returns how many were written.
+	Flush(ctx context.Context, batch int) (int, error)
+}
+
+type clickCounter struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+// NewClickCounter returns a Redis-backed ClickCounter reconciled to Postgres.
+func NewClickCounter(db *gorm.DB, redis *redis.Client) ClickCounter {
+	return &clickCounter{db: db, redis: redis}
+}
+
+func (c *clickCounter) Current(ctx context.Context, code string) (int64, error) {
+	count, err := c.redis.Get(ctx, clickCountKeyPrefix+code).Int64()
+	if err == nil {
+		return count, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return 0, err
+	}
+	return c.storedCount(ctx, code)
+}
+
+func (c *clickCounter) Claim(ctx context.Context, code string, limit int) (int64, bool, error) {
+	if err := c.load(ctx, code); err != nil {
+		return 0, false, err
+	}
+
+	key := clickCountKeyPrefix + code
+	count, err := c.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	if limit > 0 && count > int64(limit) {
+		// Give the slot back so the counter keeps reflecting successful redirects only.
+		if err := c.redis.Decr(ctx, key).Err(); err != nil {
+			return count, false, err
+		}
+		return count - 1, false, nil
+	}
+
+	if err := c.redis.SAdd(ctx, clickCountDirtyKey, code).Err(); err != nil {
+		return count, true, err
+	}
+	return count, true, nil
+}
+
+func (c *clickCounter) ClaimOnce(ctx context.Context, code, clickID string, limit int, ttl time.Duration) (int64, bool, error) {
+	key := clickClaimKeyPrefix + clickID
+	first, err := c.redis.SetNX(ctx, key, code, ttl).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	if !first {
+		count, err := c.Current(ctx, code)
+		return count, err == nil, err
+	}
+
+	count, allowed, err := c.Claim(ctx, code, limit)
+	if err != nil || !allowed {
+		// The click was not counted, so a replay must be judged afresh.
+		if delErr := c.redis.Del(ctx, key).Err(); delErr != nil && err == nil {
+			err = delErr
+		}
+	}
+	return count, allowed, err
+}
+
+func (c *clickCounter) Reset(ctx context.Context, code string) error {
+	result := c.db.WithContext(ctx).
+		Model(&model.Link{}).
+		Where("code = ?", code).
+		Update("click_count", 0)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+	return c.redis.Set(ctx, clickCountKeyPrefix+code, 0, 0).Err()
+}
+
+func (c *clickCounter) Forget(ctx context.Context, code string) error {
+	if err := c.redis.SRem(ctx, clickCountDirtyKey, code).Err(); err != nil {
+		return err
+	}
+	return c.redis.Del(ctx, clickCountKeyPrefix+code).Err()
+}
+
+func (c *clickCounter) Flush(ctx context.Context, batch int) (int, error) {
+	codes, err := c.redis.SPopN(ctx, clickCountDirtyKey, int64(batch)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for i, code := range codes {
+		count, err := c.redis.Get(ctx, clickCountKeyPrefix+code).Int64()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err == nil {
+			err = c.db.WithContext(ctx).
+				Unscoped().
+				Model(&model.Link{}).
+				Where("code = ?", code).
+				Update("click_count", count).Error
+		}
+		if err != nil {
+			// Put the unwritten codes back for the next flush.
+			c.redis.SAdd(ctx, clickCountDirtyKey, codes[i:])
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// load seeds the Redis counter from Postgres when it is missing.
+func (c *clickCounter) load(ctx context.Context, code string) error {
+	key := clickCountKeyPrefix + code
+	exists, err := c.redis.Exists(ctx, key).Result()
+	if err != nil || exists > 0 {
+		return err
+	}
+
+	count, err := c.storedCount(ctx, code)
+	if err != nil {
+		return err
+	}
+	return c.redis.SetNX(ctx, key, strconv.FormatInt(count, 10), 0).Err()
+}
+
+func (c *clickCounter) storedCount(ctx context.Context, code string) (int64, error) {
+	var count int64
+	err := c.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.Link{}).
+		Where("code = ?", code).
+		Select("click_count").
+		Scan(&count).Error
+	return count, err
+}
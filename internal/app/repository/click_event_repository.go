@@ -13,6 +13,41 @@ type ClickEventRepository interface {
 	Create(ctx context.Context, event *model.ClickEvent) error
 	UpdateStatus(ctx context.Context, id string, status string) error
 	UpdateExpiredPendingStatus(ctx context.Context, expiredBefore time.Time) (int64, error)
+	List(ctx context.Context, query ClickQuery) (*ClickPage, error)
+	VariantStats(ctx context.Context, linkCode string, includeBots bool) ([]VariantStats, error)
+}
+
+// ClickCursor marks a position in a newest-first click listing.
+type ClickCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"i"`
+}
+
+// ClickQuery selects click events of a link, newest first.
+type ClickQuery struct {
+	LinkCode string
+	Status   string
+	Limit    int
+	Offset   int
+	// IncludeBots also lists clicks classified as automated traffic.
+	IncludeBots bool
+	// After continues a previous listing; Offset is ignored when set.
+	After *ClickCursor
+}
+
+// ClickPage is a page of click events.
+type ClickPage struct {
+	Events     []model.ClickEvent
+	NextCursor *ClickCursor
+}
+
+// VariantStats counts a link's click events of one A/B variant by status.
+type VariantStats struct {
+	Variant   string
+	Visits    int64
+	Successes int64
+	Pending   int64
+	Failed    int64
 }
 
 type clickEventRepository struct {
@@ -37,4 +72,63 @@ func (r *clickEventRepository) UpdateExpiredPendingStatus(ctx context.Context, e
 		Where("status = ? AND timestamp < ?", model.ClickStatusPending, expiredBefore).
 		Update("status", model.ClickStatusFailed)
 	return result.RowsAffected, result.Error
-}
\ No newline at end of file
+}
+
+func (r *clickEventRepository) List(ctx context.Context, query ClickQuery) (*ClickPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+
+	db := r.db.WithContext(ctx).
+		Where("link_code = ?", query.LinkCode).
+		Order("timestamp DESC, id DESC")
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if !query.IncludeBots {
+		db = db.Where("is_bot = ?", false)
+	}
+	if query.After != nil {
+		db = db.Where("(timestamp, id) < (?, ?)", query.After.Timestamp, query.After.ID)
+	} else if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+
+	var events []model.ClickEvent
+	if err := db.Limit(query.Limit + 1).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	page := &ClickPage{}
+	if len(events) > query.Limit {
+		events = events[:query.Limit]
+		last := events[len(events)-1]
+		page.NextCursor = &ClickCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+	page.Events = events
+
+	return page, nil
+}
+
+func (r *clickEventRepository) VariantStats(ctx context.Context, linkCode string, includeBots bool) ([]VariantStats, error) {
+	db := r.db.WithContext(ctx).Model(&model.ClickEvent{})
+	if !includeBots {
+		db = db.Where("is_bot = ?", false)
+	}
+
+	var stats []VariantStats
+	err := db.
+		Select("variant, COUNT(*) AS visits, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS successes, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS pending, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS failed",
+			model.ClickStatusSuccess, model.ClickStatusPending, model.ClickStatusFailed).
+		Where("link_code = ? AND variant <> ''", linkCode).
+		Group("variant").
+		Order("variant").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
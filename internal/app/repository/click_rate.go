@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const clickRateKeyPrefix = "clickrate:"
+
+// ClickRateCounter counts clicks per visitor IP in a fixed window that starts with
+// the first click.
+type ClickRateCounter interface {
+	// Hit records a click from ip and returns the clicks from it in the current window.
+	Hit(ctx context.Context, ip string) (int64, error)
+}
+
+type clickRateCounter struct {
+	redis  *redis.Client
+	window time.Duration
+}
+
+// NewClickRateCounter returns a Redis-backed counter of clicks per IP within window.
+func NewClickRateCounter(redis *redis.Client, window time.Duration) ClickRateCounter {
+	return &clickRateCounter{redis: redis, window: window}
+}
+
+func (c *clickRateCounter) Hit(ctx context.Context, ip string) (int64, error) {
+	key := clickRateKeyPrefix + ip
+
+	pipe := c.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, c.window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
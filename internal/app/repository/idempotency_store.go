@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyKeyPrefix = "idempotency:"
+	// idempotencyPendingTTL bounds how long a crashed request can block retries of its key.
+	idempotencyPendingTTL = 1 * time.Minute
+)
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key.
+type IdempotencyRecord struct {
+	RequestHash string            `json:"hash"`
+	Completed   bool              `json:"completed"`
+	Status      int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// IdempotencyStore remembers responses to requests carrying an idempotency key.
+type IdempotencyStore interface {
+	// Begin claims key for a request with requestHash. When the key is already
+	// claimed it returns the existing record and false.
+	Begin(ctx context.Context, key, requestHash string) (*IdempotencyRecord, bool, error)
+	// Complete stores the final response for key, keeping it for window.
+	Complete(ctx context.Context, key string, record *IdempotencyRecord, window time.Duration) error
+	// Release forgets key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+type redisIdempotencyStore struct {
+	redis *redis.Client
+}
+
+// NewIdempotencyStore returns a Redis-backed IdempotencyStore.
+func NewIdempotencyStore(redis *redis.Client) IdempotencyStore {
+	return &redisIdempotencyStore{redis: redis}
+}
+
+func (s *redisIdempotencyStore) Begin(ctx context.Context, key, requestHash string) (*IdempotencyRecord, bool, error) {
+	pending, err := json.Marshal(IdempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return nil, false, err
+	}
+
+	acquired, err := s.redis.SetNX(ctx, idempotencyKeyPrefix+key, pending, idempotencyPendingTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if acquired {
+		return nil, true, nil
+	}
+
+	data, err := s.redis.Get(ctx, idempotencyKeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			// The previous claim expired between SETNX and GET; try once more.
+			acquired, err = s.redis.SetNX(ctx, idempotencyKeyPrefix+key, pending, idempotencyPendingTTL).Result()
+			if err != nil {
+				return nil, false, err
+			}
+			if acquired {
+				return nil, true, nil
+			}
+			return &IdempotencyRecord{RequestHash: requestHash}, false, nil
+		}
+		return nil, false, err
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false, err
+	}
+	return &record, false, nil
+}
+
+func (s *redisIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, window time.Duration) error {
+	record.Completed = true
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, idempotencyKeyPrefix+key, data, window).Err()
+}
+
+func (s *redisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.redis.Del(ctx, idempotencyKeyPrefix+key).Err()
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"gorm.io/gorm"
+)
+
+// Sort fields accepted by LinkQuery.Sort. Prefix with "-" for descending order.
+var linkSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"starts_at":  "starts_at",
+	"expires_at": "expires_at",
+	"code":       "code",
+	"url":        "url",
+}
+
+// DefaultLinkSort lists newest links first.
+const DefaultLinkSort = "-created_at"
+
+// Link states accepted by LinkQuery.State.
+const (
+	LinkStateActive    = "active"
+	LinkStateExpired   = "expired"
+	LinkStateScheduled = "scheduled"
+)
+
+// destinationHostExpr extracts the lower-cased host from links.url.
+const destinationHostExpr = `lower(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/?#]*@)?([^/?#:]+)'))`
+
+// LinkCursor marks a position in a created_at ordered listing (keyset pagination).
+type LinkCursor struct {
+	CreatedAt time.Time `json:"t"`
+	Code      string    `json:"c"`
+	Desc      bool      `json:"d"`
+}
+
+// LinkQuery describes filters, search and ordering for listing links.
+type LinkQuery struct {
+	Limit  int
+	Offset int
+	// After continues a listing sorted by created_at; Offset is ignored when set.
+	After *LinkCursor
+	// WorkspaceID restricts the listing to one workspace when set.
+	WorkspaceID string
+
+	Modes         []string
+	Disabled      *bool
+	Broken        *bool  // destination health, see model.LinkHealth
+	State         string // LinkStateActive, LinkStateExpired or LinkStateScheduled
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Host          string
+	Search        string // substring match over url and code
+	Sort          string // e.g. "-created_at", "code"
+}
+
+// LinkPage is a page of links plus the number of links matching the query.
+type LinkPage struct {
+	Links []model.Link
+	Total int64
+	// NextCursor is set when the listing is sorted by created_at and more links follow.
+	NextCursor *LinkCursor
+}
+
+// KeysetSortable reports whether sort supports cursor pagination.
+func KeysetSortable(sort string) bool {
+	return strings.TrimPrefix(sort, "-") == "created_at"
+}
+
+// ValidLinkSort reports whether sort names a supported sort field.
+func ValidLinkSort(sort string) bool {
+	_, ok := linkSortColumns[strings.TrimPrefix(sort, "-")]
+	return ok
+}
+
+func (q LinkQuery) normalized() LinkQuery {
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	if q.Sort == "" || !ValidLinkSort(q.Sort) {
+		q.Sort = DefaultLinkSort
+	}
+	return q
+}
+
+// apply adds the query's filters (not ordering or paging) to db.
+func (q LinkQuery) apply(db *gorm.DB, now time.Time) *gorm.DB {
+	if q.WorkspaceID != "" {
+		db = db.Where("workspace_id = ?", q.WorkspaceID)
+	}
+	if len(q.Modes) > 0 {
+		db = db.Where("mode IN ?", q.Modes)
+	}
+	if q.Disabled != nil {
+		db = db.Where("disabled = ?", *q.Disabled)
+	}
+	if q.Broken != nil {
+		db = db.Where("health_broken = ?", *q.Broken)
+	}
+	switch q.State {
+	case LinkStateActive:
+		db = db.Where("disabled = ? AND (expires_at IS NULL OR expires_at > ?) AND (starts_at IS NULL OR starts_at <= ?)", false, now, now)
+	case LinkStateExpired:
+		db = db.Where("expires_at IS NOT NULL AND expires_at <= ?", now)
+	case LinkStateScheduled:
+		db = db.Where("starts_at IS NOT NULL AND starts_at > ?", now)
+	}
+	if q.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *q.CreatedBefore)
+	}
+	if q.Host != "" {
+		db = db.Where(destinationHostExpr+" = ?", strings.ToLower(q.Host))
+	}
+	if q.Search != "" {
+		pattern := "%" + escapeLike(q.Search) + "%"
+		db = db.Where("url ILIKE ? OR code ILIKE ?", pattern, pattern)
+	}
+	return db
+}
+
+// applyCursor restricts db to rows strictly after the cursor position.
+func (q LinkQuery) applyCursor(db *gorm.DB) *gorm.DB {
+	if q.After == nil {
+		return db
+	}
+	if q.After.Desc {
+		return db.Where("(created_at, code) < (?, ?)", q.After.CreatedAt, q.After.Code)
+	}
+	return db.Where("(created_at, code) > (?, ?)", q.After.CreatedAt, q.After.Code)
+}
+
+// order returns the ORDER BY clause for the query, with code as tie-breaker.
+func (q LinkQuery) order() string {
+	desc := strings.HasPrefix(q.Sort, "-")
+	column := linkSortColumns[strings.TrimPrefix(q.Sort, "-")]
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	if column == "code" {
+		return "code " + direction
+	}
+	return column + " " + direction + ", code " + direction
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -4,31 +4,71 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sifan077/PowerURL/internal/app/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	// ErrLinkNotFound signals that the requested short link does not exist.
 	ErrLinkNotFound = errors.New("link not found")
+	// ErrDuplicateCode signals that another link already uses the requested code.
+	ErrDuplicateCode = errors.New("link code already exists")
+	// ErrCodeQuarantined signals that the code belonged to a purged link and is not reusable yet.
+	ErrCodeQuarantined = errors.New("link code is quarantined")
+	// ErrRevisionNotFound signals that the requested revision does not exist for the link.
+	ErrRevisionNotFound = errors.New("link revision not found")
+	// ErrVersionConflict signals that the link changed since the caller read it.
+	ErrVersionConflict = errors.New("link version conflict")
+	// ErrBatchAborted signals that an atomic batch write was rolled back because of row-level errors.
+	ErrBatchAborted = errors.New("batch aborted")
 )
 
+// LinkCodeSequence is the Postgres sequence backing sequential code generation.
+const LinkCodeSequence = "link_code_seq"
+
 const (
 	cacheKeyPrefix = "link:"
 	cacheTTL       = 1 * time.Hour
 	cacheNullTTL   = 5 * time.Minute
 	cacheNullValue = "NULL"
+
+	createBatchSize = 100
+
+	maxFlagReasonLength = 255
 )
 
 // LinkRepository defines the data access contract for short links.
 type LinkRepository interface {
 	Create(ctx context.Context, link *model.Link) error
+	CreateBatch(ctx context.Context, links []*model.Link, atomic bool) ([]error, error)
 	GetByCode(ctx context.Context, code string) (*model.Link, error)
+	GetIncludingDeleted(ctx context.Context, code string) (*model.Link, error)
+	GetByCodeUncached(ctx context.Context, code string) (*model.Link, error)
 	List(ctx context.Context, limit, offset int) ([]model.Link, error)
+	Query(ctx context.Context, query LinkQuery) (*LinkPage, error)
 	Update(ctx context.Context, link *model.Link) error
+	NextCodeSequence(ctx context.Context) (int64, error)
+	Delete(ctx context.Context, code string) error
+	Restore(ctx context.Context, code string) (*model.Link, error)
+	ListDeleted(ctx context.Context, workspaceID string, limit, offset int) ([]model.Link, error)
+	Purge(ctx context.Context, code string, quarantineUntil time.Time) error
+	ListRevisions(ctx context.Context, code string, limit, offset int) ([]model.LinkRevision, error)
+	GetRevision(ctx context.Context, code string, revisionID uint) (*model.LinkRevision, error)
+	// RevertToRevision saves link, restored from revision revisionID, like Update
+	// but recording the change as a revert.
+	RevertToRevision(ctx context.Context, link *model.Link, revisionID uint) error
+	ListAfter(ctx context.Context, afterCode string, limit int) ([]model.Link, error)
+	Flag(ctx context.Context, code, reason, action string) error
+	ClearFlag(ctx context.Context, code string) (*model.Link, error)
+	ListFlagged(ctx context.Context, limit, offset int) ([]model.Link, error)
+	UpdateHealth(ctx context.Context, code, url string, health model.LinkHealth) error
+	UpdatePreview(ctx context.Context, code string, preview model.LinkPreview) error
 }
 
 type linkRepository struct {
@@ -45,12 +85,157 @@ func NewLinkRepository(db *gorm.DB, redis *redis.Client) LinkRepository {
 }
 
 func (r *linkRepository) Create(ctx context.Context, link *model.Link) error {
-	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var quarantined int64
+		if err := tx.Model(&model.QuarantinedCode{}).
+			Where("code = ? AND until > ?", link.Code, time.Now()).
+			Count(&quarantined).Error; err != nil {
+			return err
+		}
+		if quarantined > 0 {
+			return ErrCodeQuarantined
+		}
+		if err := tx.Create(link).Error; err != nil {
+			return err
+		}
+		return tx.Create(model.NewLinkRevision(link, model.RevisionActionCreate)).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicateCode
+		}
 		return err
 	}
+
+	// Drop any negative-cache entry left by earlier lookups of this code.
+	r.purgeCache(ctx, link.Code)
 	return nil
 }
 
+// CreateBatch inserts links using multi-row inserts. The returned slice is aligned
+// with links and holds per-link failures such as ErrDuplicateCode. When atomic is
+// true nothing is written unless every link can be inserted; ErrBatchAborted is
+// returned alongside the per-link errors in that case, or with a nil slice when
+// the failing link is unknown.
+func (r *linkRepository) CreateBatch(ctx context.Context, links []*model.Link, atomic bool) ([]error, error) {
+	rowErrs := make([]error, len(links))
+	if len(links) == 0 {
+		return rowErrs, nil
+	}
+
+	codes := make([]string, len(links))
+	for i, link := range links {
+		codes[i] = link.Code
+	}
+	unavailable, err := r.unavailableCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(links))
+	pending := make([]int, 0, len(links))
+	for i, link := range links {
+		if reason, ok := unavailable[link.Code]; ok {
+			rowErrs[i] = reason
+			continue
+		}
+		if _, dup := seen[link.Code]; dup {
+			rowErrs[i] = ErrDuplicateCode
+			continue
+		}
+		seen[link.Code] = struct{}{}
+		pending = append(pending, i)
+	}
+
+	if atomic {
+		if len(pending) != len(links) {
+			return rowErrs, ErrBatchAborted
+		}
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return createWithRevisionsTx(tx, links)
+		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// Lost a race with a concurrent insert; there is no telling which row.
+			return nil, fmt.Errorf("%w: %w", ErrBatchAborted, ErrDuplicateCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.purgeCodes(ctx, codes)
+		return rowErrs, nil
+	}
+
+	for start := 0; start < len(pending); start += createBatchSize {
+		end := start + createBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		chunk := make([]*model.Link, 0, end-start)
+		chunkCodes := make([]string, 0, end-start)
+		for _, idx := range pending[start:end] {
+			chunk = append(chunk, links[idx])
+			chunkCodes = append(chunkCodes, links[idx].Code)
+		}
+
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return createWithRevisionsTx(tx, chunk)
+		})
+		if err == nil {
+			r.purgeCodes(ctx, chunkCodes)
+			continue
+		}
+
+		// Fall back to row-by-row inserts so one bad row does not sink its neighbours.
+		for _, idx := range pending[start:end] {
+			rowErrs[idx] = r.Create(ctx, links[idx])
+		}
+	}
+
+	return rowErrs, nil
+}
+
+func createWithRevisionsTx(tx *gorm.DB, links []*model.Link) error {
+	if err := tx.CreateInBatches(links, createBatchSize).Error; err != nil {
+		return err
+	}
+	revisions := make([]*model.LinkRevision, len(links))
+	for i, link := range links {
+		revisions[i] = model.NewLinkRevision(link, model.RevisionActionCreate)
+	}
+	return tx.CreateInBatches(revisions, createBatchSize).Error
+}
+
+// unavailableCodes reports which of codes are taken by a link (trashed links included)
+// or still quarantined.
+func (r *linkRepository) unavailableCodes(ctx context.Context, codes []string) (map[string]error, error) {
+	unavailable := make(map[string]error)
+
+	var taken []string
+	if err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.Link{}).
+		Where("code IN ?", codes).
+		Pluck("code", &taken).Error; err != nil {
+		return nil, err
+	}
+	for _, code := range taken {
+		unavailable[code] = ErrDuplicateCode
+	}
+
+	var quarantined []string
+	if err := r.db.WithContext(ctx).
+		Model(&model.QuarantinedCode{}).
+		Where("code IN ? AND until > ?", codes, time.Now()).
+		Pluck("code", &quarantined).Error; err != nil {
+		return nil, err
+	}
+	for _, code := range quarantined {
+		unavailable[code] = ErrCodeQuarantined
+	}
+
+	return unavailable, nil
+}
+
 func (r *linkRepository) GetByCode(ctx context.Context, code string) (*model.Link, error) {
 	cacheKey := cacheKeyPrefix + code
 
@@ -81,13 +266,55 @@ func (r *linkRepository) GetByCode(ctx context.Context, code string) (*model.Lin
 	if r.redis != nil {
 		data, err := json.Marshal(link)
 		if err == nil {
-			r.redis.Set(ctx, cacheKey, data, cacheTTL)
+			r.redis.Set(ctx, cacheKey, data, linkCacheTTL(&link, time.Now()))
 		}
 	}
 
 	return &link, nil
 }
 
+// linkCacheTTL caps cacheTTL so a cached link never outlives its next scheduled
+// state change (activation or expiry).
+func linkCacheTTL(link *model.Link, now time.Time) time.Duration {
+	ttl := cacheTTL
+	for _, at := range []*time.Time{link.StartsAt, link.ExpiresAt} {
+		if at == nil || !at.After(now) {
+			continue
+		}
+		if until := at.Sub(now); until < ttl {
+			ttl = until
+		}
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+// GetIncludingDeleted loads a link whether or not it is soft-deleted, bypassing the cache.
+func (r *linkRepository) GetIncludingDeleted(ctx context.Context, code string) (*model.Link, error) {
+	var link model.Link
+	if err := r.db.WithContext(ctx).Unscoped().Where("code = ?", code).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetByCodeUncached loads a live link straight from Postgres, e.g. before a read-modify-write.
+func (r *linkRepository) GetByCodeUncached(ctx context.Context, code string) (*model.Link, error) {
+	var link model.Link
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
 func (r *linkRepository) List(ctx context.Context, limit, offset int) ([]model.Link, error) {
 	if limit <= 0 {
 		limit = 20
@@ -108,18 +335,321 @@ func (r *linkRepository) List(ctx context.Context, limit, offset int) ([]model.L
 	return result, nil
 }
 
+func (r *linkRepository) Query(ctx context.Context, query LinkQuery) (*LinkPage, error) {
+	query = query.normalized()
+	now := time.Now()
+
+	var total int64
+	if err := query.apply(r.db.WithContext(ctx).Model(&model.Link{}), now).
+		Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	db := query.apply(r.db.WithContext(ctx), now).Order(query.order())
+	if query.After != nil {
+		db = query.applyCursor(db)
+	} else {
+		db = db.Offset(query.Offset)
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	var links []model.Link
+	if err := db.Limit(query.Limit + 1).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	page := &LinkPage{Total: total}
+	if len(links) > query.Limit {
+		links = links[:query.Limit]
+		if KeysetSortable(query.Sort) {
+			last := links[len(links)-1]
+			page.NextCursor = &LinkCursor{
+				CreatedAt: last.CreatedAt,
+				Code:      last.Code,
+				Desc:      strings.HasPrefix(query.Sort, "-"),
+			}
+		}
+	}
+	page.Links = links
+
+	return page, nil
+}
+
+// Update writes link's mutable fields. When link.Version is set the write only
+// succeeds if the stored version still matches, otherwise ErrVersionConflict is returned.
 func (r *linkRepository) Update(ctx context.Context, link *model.Link) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateLinkTx(tx, link, model.NewLinkRevision(link, model.RevisionActionUpdate))
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.redis != nil {
+		cacheKey := cacheKeyPrefix + link.Code
+		r.redis.Del(ctx, cacheKey)
+	}
+
+	return nil
+}
+
+// healthUnprobed holds the SQL value of each health column for a link that was never probed.
+var healthUnprobed = map[string]string{
+	"health_status":     "0",
+	"health_latency_ms": "0",
+	"health_failures":   "0",
+	"health_broken":     "false",
+	"health_error":      "''",
+	"health_checked_at": "NULL",
+}
+
+// updateLinkTx writes link's mutable fields and bumps its version, conditional on
+// link.Version unless it is zero, then reloads it and records revision.
+func updateLinkTx(tx *gorm.DB, link *model.Link, revision *model.LinkRevision) error {
+	query := tx.Model(&model.Link{}).Where("code = ?", link.Code)
+	if link.Version > 0 {
+		query = query.Where("version = ?", link.Version)
+	}
+	columns := map[string]interface{}{
+		"url":                link.URL,
+		"mode":               link.Mode,
+		"timer_seconds":      link.TimerSeconds,
+		"disabled":           link.Disabled,
+		"password_hash":      link.PasswordHash,
+		"starts_at":          link.StartsAt,
+		"expires_at":         link.ExpiresAt,
+		"max_clicks":         link.MaxClicks,
+		"rules":              link.Rules,
+		"geo_targets":        link.GeoTargets,
+		"variants":           link.Variants,
+		"query_policy":       link.QueryPolicy,
+		"forward_path":       link.ForwardPath,
+		"social_title":       link.Social.Title,
+		"social_description": link.Social.Description,
+		"social_image_url":   link.Social.ImageURL,
+		"version":            gorm.Expr("version + 1"),
+	}
+	// Probe results describe the previous destination; a new URL starts out unprobed.
+	for column, unprobed := range healthUnprobed {
+		columns[column] = gorm.Expr("CASE WHEN url = ? THEN "+column+" ELSE "+unprobed+" END", link.URL)
+	}
+	result := query.Updates(columns)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if link.Version > 0 {
+			var count int64
+			if err := tx.Model(&model.Link{}).Where("code = ?", link.Code).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrVersionConflict
+			}
+		}
+		return ErrLinkNotFound
+	}
+
+	if err := tx.Where("code = ?", link.Code).First(link).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(revision).Error
+}
+
+func (r *linkRepository) ListRevisions(ctx context.Context, code string, limit, offset int) ([]model.LinkRevision, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var result []model.LinkRevision
+	if err := r.db.WithContext(ctx).
+		Where("link_code = ?", code).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *linkRepository) GetRevision(ctx context.Context, code string, revisionID uint) (*model.LinkRevision, error) {
+	var revision model.LinkRevision
+	if err := r.db.WithContext(ctx).Where("id = ? AND link_code = ?", revisionID, code).First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *linkRepository) RevertToRevision(ctx context.Context, link *model.Link, revisionID uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		snapshot := model.NewLinkRevision(link, model.RevisionActionRevert)
+		snapshot.RevertedFrom = &revisionID
+		return updateLinkTx(tx, link, snapshot)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.purgeCache(ctx, link.Code)
+	return nil
+}
+
+// ListAfter returns up to limit live links ordered by code, starting after afterCode,
+// so every link can be visited in batches.
+func (r *linkRepository) ListAfter(ctx context.Context, afterCode string, limit int) ([]model.Link, error) {
+	var result []model.Link
+	if err := r.db.WithContext(ctx).
+		Where("code > ?", afterCode).
+		Order("code").
+		Limit(limit).
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Flag marks the link as pointing to a malicious destination. The link's settings
+// and updated_at are left alone and no revision is recorded.
+func (r *linkRepository) Flag(ctx context.Context, code, reason, action string) error {
+	if len(reason) > maxFlagReasonLength {
+		reason = reason[:maxFlagReasonLength]
+	}
+	result := r.db.WithContext(ctx).
+		Model(&model.Link{}).
+		Where("code = ?", code).
+		UpdateColumns(map[string]interface{}{
+			"flag_reason": reason,
+			"flag_action": action,
+			"flagged_at":  time.Now(),
+			"version":     gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+
+	r.purgeCache(ctx, code)
+	return nil
+}
+
+// ClearFlag lifts the link's flag and records the review, returning the updated link.
+func (r *linkRepository) ClearFlag(ctx context.Context, code string) (*model.Link, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Link{}).
+		Where("code = ?", code).
+		UpdateColumns(map[string]interface{}{
+			"flag_reason":      "",
+			"flag_action":      "",
+			"flagged_at":       nil,
+			"flag_reviewed_at": time.Now(),
+			"version":          gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrLinkNotFound
+	}
+
+	r.purgeCache(ctx, code)
+	return r.GetByCodeUncached(ctx, code)
+}
+
+// ListFlagged returns flagged links of every workspace, most recently flagged first.
+func (r *linkRepository) ListFlagged(ctx context.Context, limit, offset int) ([]model.Link, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var result []model.Link
+	if err := r.db.WithContext(ctx).
+		Where("flagged_at IS NOT NULL").
+		Order("flagged_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateHealth stores the outcome of probing url unless the link points elsewhere
+// by now. Probe results are not settings: the version, updated_at and revisions
+// are left alone, so a probe never makes a client's If-Match fail.
+func (r *linkRepository) UpdateHealth(ctx context.Context, code, url string, health model.LinkHealth) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.Link{}).
+		Where("code = ? AND url = ?", code, url).
+		UpdateColumns(map[string]interface{}{
+			"health_status":     health.Status,
+			"health_latency_ms": health.LatencyMs,
+			"health_failures":   health.Failures,
+			"health_broken":     health.Broken,
+			"health_error":      health.Error,
+			"health_checked_at": health.CheckedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+
+	r.purgeCache(ctx, code)
+	return nil
+}
+
+// UpdatePreview stores preview unless the link's URL changed since preview.URL was
+// queued; like UpdateHealth it leaves the version alone.
+func (r *linkRepository) UpdatePreview(ctx context.Context, code string, preview model.LinkPreview) error {
 	result := r.db.WithContext(ctx).
 		Model(&model.Link{}).
-		Where("code = ?", link.Code).
-		Updates(map[string]interface{}{
-			"url":           link.URL,
-			"mode":          link.Mode,
-			"timer_seconds": link.TimerSeconds,
-			"disabled":      link.Disabled,
-			"expires_at":    link.ExpiresAt,
+		Where("code = ? AND url = ?", code, preview.URL).
+		UpdateColumns(map[string]interface{}{
+			"preview_url":         preview.URL,
+			"preview_title":       preview.Title,
+			"preview_description": preview.Description,
+			"preview_image_url":   preview.ImageURL,
+			"preview_favicon_url": preview.FaviconURL,
+			"preview_fetched_at":  preview.FetchedAt,
 		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+
+	r.purgeCache(ctx, code)
+	return nil
+}
+
+func (r *linkRepository) NextCodeSequence(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.WithContext(ctx).Raw("SELECT nextval(?)", LinkCodeSequence).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
 
+func (r *linkRepository) Delete(ctx context.Context, code string) error {
+	result := r.db.WithContext(ctx).Where("code = ?", code).Delete(&model.Link{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -127,14 +657,100 @@ func (r *linkRepository) Update(ctx context.Context, link *model.Link) error {
 		return ErrLinkNotFound
 	}
 
-	if err := r.db.WithContext(ctx).Where("code = ?", link.Code).First(link).Error; err != nil {
+	r.purgeCache(ctx, code)
+	return nil
+}
+
+func (r *linkRepository) Restore(ctx context.Context, code string) (*model.Link, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.Link{}).
+		Where("code = ? AND deleted_at IS NOT NULL", code).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrLinkNotFound
+	}
+
+	var link model.Link
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&link).Error; err != nil {
+		return nil, err
+	}
+
+	r.purgeCache(ctx, code)
+	return &link, nil
+}
+
+// ListDeleted lists soft-deleted links, restricted to workspaceID when it is set.
+func (r *linkRepository) ListDeleted(ctx context.Context, workspaceID string, limit, offset int) ([]model.Link, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	db := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL")
+	if workspaceID != "" {
+		db = db.Where("workspace_id = ?", workspaceID)
+	}
+
+	var result []model.Link
+	if err := db.
+		Order("deleted_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *linkRepository) Purge(ctx context.Context, code string, quarantineUntil time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("code = ?", code).Delete(&model.Link{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLinkNotFound
+		}
+		if err := tx.Where("link_code = ?", code).Delete(&model.LinkRevision{}).Error; err != nil {
+			return err
+		}
+
+		if !quarantineUntil.After(time.Now()) {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"until"}),
+		}).Create(&model.QuarantinedCode{Code: code, Until: quarantineUntil}).Error
+	})
+	if err != nil {
 		return err
 	}
 
+	r.purgeCache(ctx, code)
+	return nil
+}
+
+func (r *linkRepository) purgeCache(ctx context.Context, code string) {
 	if r.redis != nil {
-		cacheKey := cacheKeyPrefix + link.Code
-		r.redis.Del(ctx, cacheKey)
+		r.redis.Del(ctx, cacheKeyPrefix+code)
 	}
+}
 
-	return nil
-}
\ No newline at end of file
+func (r *linkRepository) purgeCodes(ctx context.Context, codes []string) {
+	if r.redis == nil || len(codes) == 0 {
+		return
+	}
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = cacheKeyPrefix + code
+	}
+	r.redis.Del(ctx, keys...)
+}
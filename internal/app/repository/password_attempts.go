@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const passwordAttemptKeyPrefix = "pwattempts:"
+
+// refundAttemptScript decrements an attempt counter without recreating it after it expired.
+var refundAttemptScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 and tonumber(redis.call("GET", KEYS[1])) > 0 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
+// PasswordAttemptLimiter throttles password guesses per link. Attempts are counted
+// in a fixed window that starts with the first attempt; successful ones are given back.
+type PasswordAttemptLimiter interface {
+	// Begin reserves an attempt for code. Once the window's attempts are used up it
+	// reports false along with the time until the window resets.
+	Begin(ctx context.Context, code string) (bool, time.Duration, error)
+	// Succeeded returns the attempt reserved by Begin so only failures count.
+	Succeeded(ctx context.Context, code string) error
+}
+
+type passwordAttemptLimiter struct {
+	redis       *redis.Client
+	maxAttempts int64
+	window      time.Duration
+}
+
+// NewPasswordAttemptLimiter returns a Redis-backed limiter allowing maxAttempts
+// failed attempts per link within window.
+func NewPasswordAttemptLimiter(redis *redis.Client, maxAttempts int, window time.Duration) PasswordAttemptLimiter {
+	return &passwordAttemptLimiter{redis: redis, maxAttempts: int64(maxAttempts), window: window}
+}
+
+func (l *passwordAttemptLimiter) Begin(ctx context.Context, code string) (bool, time.Duration, error) {
+	key := passwordAttemptKeyPrefix + code
+
+	pipe := l.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, l.window)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, 0, err
+	}
+
+	if incr.Val() <= l.maxAttempts {
+		return true, 0, nil
+	}
+	return false, ttl.Val(), nil
+}
+
+func (l *passwordAttemptLimiter) Succeeded(ctx context.Context, code string) error {
+	return refundAttemptScript.Run(ctx, l.redis, []string{passwordAttemptKeyPrefix + code}).Err()
+}
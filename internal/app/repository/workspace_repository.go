@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrWorkspaceNotFound signals that no workspace matches the lookup.
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrWorkspaceNotEmpty is returned when deleting a workspace that still owns links.
+	ErrWorkspaceNotEmpty = errors.New("workspace still owns links")
+	// ErrMemberNotFound signals that the member does not belong to the workspace.
+	ErrMemberNotFound = errors.New("workspace member not found")
+)
+
+// WorkspaceRepository defines the data access contract for workspaces and their members.
+type WorkspaceRepository interface {
+	Create(ctx context.Context, workspace *model.Workspace, owner *model.WorkspaceMember) error
+	Ensure(ctx context.Context, workspace *model.Workspace) error
+	Get(ctx context.Context, id string) (*model.Workspace, error)
+	List(ctx context.Context, limit, offset int) ([]model.Workspace, error)
+	Rename(ctx context.Context, id, name string) (*model.Workspace, error)
+	Delete(ctx context.Context, id string) error
+	SaveMember(ctx context.Context, member *model.WorkspaceMember) error
+	RemoveMember(ctx context.Context, workspaceID, memberID string) error
+	ListMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error)
+	GetMember(ctx context.Context, workspaceID, memberID string) (*model.WorkspaceMember, error)
+}
+
+type workspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository returns a GORM-backed WorkspaceRepository.
+func NewWorkspaceRepository(db *gorm.DB) WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+// Create inserts the workspace and, when owner is set, its first member.
+func (r *workspaceRepository) Create(ctx context.Context, workspace *model.Workspace, owner *model.WorkspaceMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		if owner == nil {
+			return nil
+		}
+		owner.WorkspaceID = workspace.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// Ensure inserts the workspace unless one with the same ID already exists.
+func (r *workspaceRepository) Ensure(ctx context.Context, workspace *model.Workspace) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(workspace).Error
+}
+
+func (r *workspaceRepository) Get(ctx context.Context, id string) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *workspaceRepository) List(ctx context.Context, limit, offset int) ([]model.Workspace, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var result []model.Workspace
+	if err := r.db.WithContext(ctx).
+		Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *workspaceRepository) Rename(ctx context.Context, id, name string) (*model.Workspace, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Workspace{}).
+		Where("id = ?", id).
+		Update("name", name)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWorkspaceNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+// Delete removes an empty workspace together with its memberships. Soft-deleted
+// links still count, since they can be restored into the workspace.
+func (r *workspaceRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var links int64
+		if err := tx.Unscoped().Model(&model.Link{}).Where("workspace_id = ?", id).Count(&links).Error; err != nil {
+			return err
+		}
+		if links > 0 {
+			return ErrWorkspaceNotEmpty
+		}
+
+		if err := tx.Where("workspace_id = ?", id).Delete(&model.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&model.Workspace{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWorkspaceNotFound
+		}
+		return nil
+	})
+}
+
+// SaveMember adds a member or updates the role of an existing one.
+func (r *workspaceRepository) SaveMember(ctx context.Context, member *model.WorkspaceMember) error {
+	if _, err := r.Get(ctx, member.WorkspaceID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "member_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role"}),
+		}).
+		Create(member).Error
+}
+
+func (r *workspaceRepository) RemoveMember(ctx context.Context, workspaceID, memberID string) error {
+	result := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND member_id = ?", workspaceID, memberID).
+		Delete(&model.WorkspaceMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+func (r *workspaceRepository) ListMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	if _, err := r.Get(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+
+	var result []model.WorkspaceMember
+	if err := r.db.WithContext(ctx).
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at ASC").
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *workspaceRepository) GetMember(ctx context.Context, workspaceID, memberID string) (*model.WorkspaceMember, error) {
+	var member model.WorkspaceMember
+	if err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND member_id = ?", workspaceID, memberID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
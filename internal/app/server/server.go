@@ -2,45 +2,73 @@ package server
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
+	"github.com/sifan077/PowerURL/config"
 	"github.com/sifan077/PowerURL/internal/app/repository"
 	"github.com/sifan077/PowerURL/internal/app/service"
 	inthttp "github.com/sifan077/PowerURL/internal/http/handler"
 	"github.com/sifan077/PowerURL/internal/http/middleware"
+	"github.com/sifan077/PowerURL/internal/infra/geoip"
 	"go.uber.org/zap"
 )
 
+const (
+	defaultQuarantinePeriod  = 30 * 24 * time.Hour
+	defaultIdempotencyWindow = 24 * time.Hour
+
+	defaultPasswordMaxAttempts   = 10
+	defaultPasswordAttemptWindow = 15 * time.Minute
+
+	defaultThreatScanInterval = 6 * time.Hour
+
+	defaultBotRateWindow = time.Minute
+)
+
 // Dependencies bundles infrastructure dependencies required by the HTTP server.
 type Dependencies struct {
-	Logger     *zap.Logger
-	Postgres   *pgxpool.Pool
-	Redis      *redis.Client
-	NATS       *nats.Conn
-	JetStream  nats.JetStreamContext
-	Links      repository.LinkRepository
-	ClickEvents repository.ClickEventRepository
-	Secret     []byte
+	Logger        *zap.Logger
+	Postgres      *pgxpool.Pool
+	Redis         *redis.Client
+	NATS          *nats.Conn
+	JetStream     nats.JetStreamContext
+	Links         repository.LinkRepository
+	ClickEvents   repository.ClickEventRepository
+	Secret        []byte
+	Config        *config.Config
+	CodeGenerator service.CodeGenerator
+	CodePolicy    *service.CodePolicy
+	URLPolicy     *service.URLPolicy
+	APIKeys       service.APIKeyService
+	Workspaces    service.WorkspaceService
+	ClickCounter  repository.ClickCounter
+	GeoIP         *geoip.Database
+	ThreatFeed    service.ThreatFeed
+	Datacenters   service.DatacenterRanges
 }
 
 // Server wraps the Fiber application and its dependencies.
 type Server struct {
-	app                 *fiber.App
-	deps                Dependencies
-	clickTimeoutChecker *service.ClickTimeoutChecker
+	app                  *fiber.App
+	deps                 Dependencies
+	clickTimeoutChecker  *service.ClickTimeoutChecker
+	clickCountReconciler *service.ClickCountReconciler
+	threatScanner        *service.ThreatScanner
+	healthChecker        *service.HealthChecker
 }
 
 // New creates a new HTTP server instance with default routes.
 func New(deps Dependencies) *Server {
 	app := fiber.New(fiber.Config{
-		BodyLimit:       4 * 1024 * 1024, // 4MB
-		ReadTimeout:     10 * time.Second,
-		WriteTimeout:    10 * time.Second,
-		IdleTimeout:     30 * time.Second,
+		BodyLimit:        4 * 1024 * 1024, // 4MB
+		ReadTimeout:      10 * time.Second,
+		WriteTimeout:     10 * time.Second,
+		IdleTimeout:      30 * time.Second,
 		DisableKeepalive: false,
 	})
 
@@ -66,6 +94,15 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if s.clickTimeoutChecker != nil {
 		s.clickTimeoutChecker.Stop()
 	}
+	if s.clickCountReconciler != nil {
+		s.clickCountReconciler.Stop()
+	}
+	if s.threatScanner != nil {
+		s.threatScanner.Stop()
+	}
+	if s.healthChecker != nil {
+		s.healthChecker.Stop()
+	}
 	return s.app.ShutdownWithContext(ctx)
 }
 
@@ -73,6 +110,41 @@ func (s *Server) startBackgroundServices() {
 	// Start click timeout checker with 60 seconds TTL
 	s.clickTimeoutChecker = service.NewClickTimeoutChecker(s.deps.Logger, s.deps.ClickEvents, 60*time.Second)
 	s.clickTimeoutChecker.Start()
+
+	if s.deps.ClickCounter != nil {
+		s.clickCountReconciler = service.NewClickCountReconciler(s.deps.Logger, s.deps.ClickCounter, 10*time.Second)
+		s.clickCountReconciler.Start()
+	}
+
+	if s.deps.ThreatFeed != nil {
+		s.threatScanner = service.NewThreatScanner(
+			s.deps.Logger,
+			s.deps.Links,
+			s.deps.ThreatFeed,
+			s.threatFeedConfig().Action,
+			parseDuration(s.threatFeedConfig().ScanInterval, defaultThreatScanInterval),
+		)
+		s.threatScanner.Start()
+	}
+
+	if health := s.linksConfig().HealthCheck; health.Enabled {
+		deps := service.HealthCheckerDeps{
+			Logger:               s.deps.Logger,
+			Repo:                 s.deps.Links,
+			AllowPrivateNetworks: s.linksConfig().URLPolicy.AllowPrivateNetworks,
+			Interval:             parseDuration(health.Interval, 0),
+			Timeout:              parseDuration(health.Timeout, 0),
+			Concurrency:          health.Concurrency,
+			HostInterval:         parseDuration(health.HostInterval, 0),
+			FailureThreshold:     health.FailureThreshold,
+			UserAgent:            health.UserAgent,
+		}
+		if s.deps.NATS != nil {
+			deps.Events = service.NewLinkEventPublisher(s.deps.NATS)
+		}
+		s.healthChecker = service.NewHealthChecker(deps)
+		s.healthChecker.Start()
+	}
 }
 
 func (s *Server) registerMiddleware() {
@@ -81,12 +153,12 @@ func (s *Server) registerMiddleware() {
 	s.app.Use(middleware.Recovery(s.deps.Logger))
 	s.app.Use(middleware.RequestID())
 	s.app.Use(middleware.Logger(s.deps.Logger))
-	s.app.Use(middleware.CORS())
+	s.app.Use(middleware.CORS(s.securityConfig().CORSAllowedOrigins...))
 	s.app.Use(middleware.RateLimit(s.deps.Redis, rateLimitConfig, s.deps.Logger))
 }
 
 func (s *Server) registerRoutes() {
-	clickPublisher := service.NewClickPublisher(s.deps.JetStream)
+	clickPublisher := service.NewClickPublisher(s.deps.JetStream, s.botClassifier())
 	clickConsumer := service.NewClickConsumer(s.deps.JetStream, s.deps.Logger, s.deps.ClickEvents)
 
 	// Start click event consumer
@@ -95,21 +167,175 @@ func (s *Server) registerRoutes() {
 	}
 
 	redirectHandler := inthttp.NewRedirectHandler(inthttp.RedirectDeps{
-		Logger:         s.deps.Logger,
-		Links:          s.deps.Links,
-		ClickEvents:    s.deps.ClickEvents,
-		Secret:         s.deps.Secret,
-		ClickPublisher: clickPublisher,
+		Logger:          s.deps.Logger,
+		Links:           s.deps.Links,
+		ClickEvents:     s.deps.ClickEvents,
+		Secret:          s.deps.Secret,
+		ClickPublisher:  clickPublisher,
+		ClickCounter:    s.deps.ClickCounter,
+		NotYetActiveURL: s.linksConfig().NotYetActiveURL,
+		PasswordAttempts: repository.NewPasswordAttemptLimiter(
+			s.deps.Redis,
+			s.passwordMaxAttempts(),
+			parseDuration(s.linksConfig().Password.AttemptWindow, defaultPasswordAttemptWindow),
+		),
+		RulesLocation: s.rulesLocation(),
+		GeoIP:         s.deps.GeoIP,
+		Crawlers:      service.NewCrawlerDetector(s.linksConfig().SocialCrawlers),
 	})
-	redirectHandler.Register(s.app)
 
 	// Register API handler
-	linkService := service.NewLinkService(s.deps.Links)
+	linkService := service.NewLinkServiceWithDeps(service.LinkServiceDeps{
+		Logger:           s.deps.Logger,
+		Repo:             s.deps.Links,
+		CodeGenerator:    s.deps.CodeGenerator,
+		CodePolicy:       s.deps.CodePolicy,
+		URLPolicy:        s.deps.URLPolicy,
+		ThreatFeed:       s.deps.ThreatFeed,
+		MaxCodeAttempts:  s.linksConfig().CodeGen.MaxAttempts,
+		QuarantinePeriod: parseDuration(s.linksConfig().QuarantinePeriod, defaultQuarantinePeriod),
+		ClickCounter:     s.deps.ClickCounter,
+		Metadata:         s.startMetadataFetching(),
+	})
 	apiHandler := inthttp.NewAPIHandler(inthttp.APIDeps{
-		Logger:      s.deps.Logger,
-		LinkService: linkService,
+		Logger:            s.deps.Logger,
+		LinkService:       linkService,
+		ClickService:      service.NewClickService(s.deps.ClickEvents),
+		APIKeyService:     s.deps.APIKeys,
+		WorkspaceService:  s.deps.Workspaces,
+		Idempotency:       repository.NewIdempotencyStore(s.deps.Redis),
+		IdempotencyWindow: parseDuration(s.linksConfig().IdempotencyWindow, defaultIdempotencyWindow),
+		Auth: []fiber.Handler{
+			middleware.APIKeyAuth(s.deps.APIKeys, s.deps.Logger),
+			middleware.WorkspaceScope(s.deps.Workspaces, s.deps.Logger),
+		},
 	})
-	apiHandler.Register(s.app)
+	mountHandlers(s.app, apiHandler, redirectHandler)
+
+	// Short codes share the root path with every other route, so their first segments are off limits.
+	if s.deps.CodePolicy != nil {
+		s.deps.CodePolicy.Reserve(s.routePrefixes()...)
+	}
+}
+
+// mountHandlers registers the API before the redirect routes. Fiber matches routes in
+// the order they were registered, and the wildcard routes of prefix links would
+// otherwise catch every multi-segment path under /api.
+func mountHandlers(router fiber.Router, api *inthttp.APIHandler, redirect *inthttp.RedirectHandler) {
+	api.Register(router)
+	redirect.Register(router)
+}
+
+// startMetadataFetching starts the consumer that fetches link previews and returns
+// the publisher queueing them, or nil when previews are disabled.
+func (s *Server) startMetadataFetching() service.MetadataRequester {
+	cfg := s.linksConfig().Metadata
+	if !cfg.Enabled || s.deps.JetStream == nil {
+		return nil
+	}
+	fetcher, err := service.NewMetadataFetcher(cfg, nil, s.linksConfig().URLPolicy.AllowPrivateNetworks)
+	if err != nil {
+		s.deps.Logger.Error("link previews disabled", zap.Error(err))
+		return nil
+	}
+	consumer := service.NewMetadataConsumer(s.deps.JetStream, s.deps.Logger, s.deps.Links, fetcher)
+	if err := consumer.Start(); err != nil {
+		s.deps.Logger.Error("failed to start metadata consumer", zap.Error(err))
+		return nil
+	}
+	return service.NewMetadataPublisher(s.deps.JetStream, s.deps.Logger)
+}
+
+// routePrefixes returns the static first path segments of all registered routes.
+func (s *Server) routePrefixes() []string {
+	var prefixes []string
+	for _, route := range s.app.GetRoutes(true) {
+		segment := strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2)[0]
+		if segment == "" || strings.ContainsAny(segment, ":*+") {
+			continue
+		}
+		prefixes = append(prefixes, segment)
+	}
+	return prefixes
+}
+
+func (s *Server) linksConfig() config.LinksConfig {
+	if s.deps.Config == nil {
+		return config.LinksConfig{}
+	}
+	return s.deps.Config.Links
+}
+
+func (s *Server) passwordMaxAttempts() int {
+	if attempts := s.linksConfig().Password.MaxAttempts; attempts > 0 {
+		return attempts
+	}
+	return defaultPasswordMaxAttempts
+}
+
+// rulesLocation resolves the configured routing-rule timezone, falling back to UTC.
+func (s *Server) rulesLocation() *time.Location {
+	name := s.linksConfig().RulesTimezone
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		s.deps.Logger.Warn("invalid rules timezone, using UTC", zap.String("timezone", name), zap.Error(err))
+		return time.UTC
+	}
+	return location
+}
+
+func (s *Server) threatFeedConfig() config.ThreatFeedConfig {
+	if s.deps.Config == nil {
+		return config.ThreatFeedConfig{}
+	}
+	return s.deps.Config.ThreatFeed
+}
+
+func (s *Server) botDetectionConfig() config.BotDetectionConfig {
+	if s.deps.Config == nil {
+		return config.BotDetectionConfig{}
+	}
+	return s.deps.Config.BotDetection
+}
+
+// botClassifier returns the classifier marking bot clicks, or nil when bot
+// detection is disabled.
+func (s *Server) botClassifier() *service.BotClassifier {
+	cfg := s.botDetectionConfig()
+	if !cfg.Enabled {
+		return nil
+	}
+	deps := service.BotClassifierDeps{
+		Logger:      s.deps.Logger,
+		UserAgents:  cfg.UserAgents,
+		Datacenters: s.deps.Datacenters,
+		RateLimit:   cfg.RateLimit,
+	}
+	if s.deps.Redis != nil {
+		deps.Rates = repository.NewClickRateCounter(s.deps.Redis, parseDuration(cfg.RateWindow, defaultBotRateWindow))
+	}
+	return service.NewBotClassifier(deps)
+}
+
+func (s *Server) securityConfig() config.SecurityConfig {
+	if s.deps.Config == nil {
+		return config.SecurityConfig{}
+	}
+	return s.deps.Config.Security
+}
+
+// parseDuration parses a config duration string, falling back when it is empty or malformed.
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration
+	}
+	return fallback
 }
 
 func (s *Server) registerNotFoundHandler() {
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	inthttp "github.com/sifan077/PowerURL/internal/http/handler"
+)
+
+// emptyLinkRepository knows no links; other methods are not used by these tests.
+type emptyLinkRepository struct {
+	repository.LinkRepository
+}
+
+func (emptyLinkRepository) GetByCode(ctx context.Context, code string) (*model.Link, error) {
+	return nil, repository.ErrLinkNotFound
+}
+
+func TestMountHandlers_APIRoutesWinOverPrefixLinks(t *testing.T) {
+	app := fiber.New()
+	mountHandlers(app,
+		inthttp.NewAPIHandler(inthttp.APIDeps{}),
+		inthttp.NewRedirectHandler(inthttp.RedirectDeps{Links: emptyLinkRepository{}}),
+	)
+
+	// Without an API key the API answers 401; the redirect handler would answer 404.
+	for _, tc := range []struct{ method, path string }{
+		{fiber.MethodGet, "/api/links"},
+		{fiber.MethodPost, "/api/links"},
+		{fiber.MethodGet, "/api/links/promo/clicks"},
+		{fiber.MethodPost, "/api/links/bulk"},
+	} {
+		resp, err := app.Test(httptest.NewRequest(tc.method, tc.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("%s %s: expected the API handler (401), got %d", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/promo/docs/intro", nil))
+	if err != nil {
+		t.Fatalf("GET /promo/docs/intro: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("expected prefix link paths to reach the redirect handler (404), got %d", resp.StatusCode)
+	}
+}
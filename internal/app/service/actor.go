@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+type actorContextKey struct{}
+
+// Actor identifies who is calling a service and which workspace they act in.
+type Actor struct {
+	WorkspaceID string
+	OwnerID     string
+}
+
+// WithActor returns a context whose link operations are scoped to actor's workspace.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	if actor.WorkspaceID == "" {
+		actor.WorkspaceID = model.DefaultWorkspaceID
+	}
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor. Calls without an actor
+// (internal jobs, tests) are not scoped to a workspace.
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok
+}
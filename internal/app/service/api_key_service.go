@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyTokenPrefix = "pu_"
+	apiKeySecretLen   = 40
+	apiKeyDisplayLen  = len(apiKeyTokenPrefix) + 8
+)
+
+// ErrInvalidAPIKey is returned when a presented API key is unknown, revoked or expired.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyService manages API keys and authenticates requests bearing them.
+type APIKeyService interface {
+	CreateKey(ctx context.Context, input CreateAPIKeyInput) (*model.APIKey, string, error)
+	ListKeys(ctx context.Context, limit, offset int) ([]model.APIKey, error)
+	RevokeKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, token string) (*model.APIKey, error)
+	EnsureKey(ctx context.Context, name, token string, scopes []string) error
+}
+
+// CreateAPIKeyInput captures data required to mint an API key.
+type CreateAPIKeyInput struct {
+	Name        string
+	Scopes      []string
+	ExpiresAt   *time.Time
+	WorkspaceID string // defaults to model.DefaultWorkspaceID
+	OwnerID     string // must be a member of WorkspaceID when set
+}
+
+type apiKeyService struct {
+	repo       repository.APIKeyRepository
+	workspaces repository.WorkspaceRepository
+	logger     *zap.Logger
+}
+
+// NewAPIKeyService returns an APIKeyService backed by the given repositories.
+// workspaces may be nil, in which case key workspaces are not validated.
+func NewAPIKeyService(repo repository.APIKeyRepository, workspaces repository.WorkspaceRepository, logger *zap.Logger) APIKeyService {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &apiKeyService{repo: repo, workspaces: workspaces, logger: logger}
+}
+
+// HashAPIKey returns the stored representation of a plaintext key.
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, input CreateAPIKeyInput) (*model.APIKey, string, error) {
+	if fieldErr := validateScopes(input.Scopes); fieldErr != nil {
+		return nil, "", &ValidationError{Fields: []FieldError{*fieldErr}}
+	}
+	if input.WorkspaceID == "" {
+		input.WorkspaceID = model.DefaultWorkspaceID
+	}
+	if err := s.validateWorkspace(ctx, input.WorkspaceID, input.OwnerID); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := NewRandomCodeGenerator(apiKeySecretLen).Generate(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate api key: %w", err)
+	}
+	token := apiKeyTokenPrefix + secret
+
+	key := &model.APIKey{
+		ID:          uuid.New().String(),
+		Name:        input.Name,
+		WorkspaceID: input.WorkspaceID,
+		OwnerID:     input.OwnerID,
+		Prefix:      token[:apiKeyDisplayLen],
+		KeyHash:     HashAPIKey(token),
+		Scopes:      input.Scopes,
+		ExpiresAt:   input.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("create api key: %w", err)
+	}
+	return key, token, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, limit, offset int) ([]model.APIKey, error) {
+	keys, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, id string) error {
+	if err := s.repo.Revoke(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, token string) (*model.APIKey, error) {
+	if token == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, HashAPIKey(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("load api key: %w", err)
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	go s.markUsed(key.ID, now)
+	return key, nil
+}
+
+// EnsureKey provisions a key with a known plaintext (e.g. a bootstrap admin key)
+// unless it already exists.
+func (s *apiKeyService) EnsureKey(ctx context.Context, name, token string, scopes []string) error {
+	hash := HashAPIKey(token)
+	if _, err := s.repo.GetByHash(ctx, hash); err == nil {
+		return nil
+	} else if !errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return fmt.Errorf("load api key: %w", err)
+	}
+
+	prefix := token
+	if len(prefix) > apiKeyDisplayLen {
+		prefix = prefix[:apiKeyDisplayLen]
+	}
+	key := &model.APIKey{
+		ID:          uuid.New().String(),
+		Name:        name,
+		WorkspaceID: model.DefaultWorkspaceID,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Scopes:      scopes,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+func (s *apiKeyService) validateWorkspace(ctx context.Context, workspaceID, ownerID string) error {
+	if s.workspaces == nil {
+		return nil
+	}
+	if _, err := s.workspaces.Get(ctx, workspaceID); err != nil {
+		if errors.Is(err, repository.ErrWorkspaceNotFound) {
+			return &ValidationError{Fields: []FieldError{{Field: "workspace_id", Message: "workspace does not exist"}}}
+		}
+		return fmt.Errorf("get workspace: %w", err)
+	}
+	if ownerID == "" {
+		return nil
+	}
+	if _, err := s.workspaces.GetMember(ctx, workspaceID, ownerID); err != nil {
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			return &ValidationError{Fields: []FieldError{{Field: "owner_id", Message: "is not a member of the workspace"}}}
+		}
+		return fmt.Errorf("get workspace member: %w", err)
+	}
+	return nil
+}
+
+func (s *apiKeyService) markUsed(id string, at time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.MarkUsed(ctx, id, at); err != nil {
+		s.logger.Warn("failed to record api key usage", zap.String("key_id", id), zap.Error(err))
+	}
+}
+
+func validateScopes(scopes []string) *FieldError {
+	if len(scopes) == 0 {
+		return &FieldError{Field: "scopes", Message: "at least one scope is required"}
+	}
+	for _, scope := range scopes {
+		if !model.KnownScope(scope) {
+			return &FieldError{
+				Field:   "scopes",
+				Message: fmt.Sprintf("unknown scope %q (expected one of: %s)", scope, strings.Join([]string{model.ScopeLinksRead, model.ScopeLinksWrite, model.ScopeAdmin}, ", ")),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+)
+
+type memoryAPIKeyRepository struct {
+	mu   sync.Mutex
+	keys map[string]*model.APIKey
+}
+
+func newMemoryAPIKeyRepository() *memoryAPIKeyRepository {
+	return &memoryAPIKeyRepository{keys: make(map[string]*model.APIKey)}
+}
+
+func (m *memoryAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *key
+	m.keys[key.ID] = &copied
+	return nil
+}
+
+func (m *memoryAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.KeyHash == hash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func (m *memoryAPIKeyRepository) List(ctx context.Context, limit, offset int) ([]model.APIKey, error) {
+	return nil, nil
+}
+
+func (m *memoryAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[id]
+	if !ok {
+		return repository.ErrAPIKeyNotFound
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+func (m *memoryAPIKeyRepository) MarkUsed(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	repo := newMemoryAPIKeyRepository()
+	svc := NewAPIKeyService(repo, nil, nil)
+	ctx := context.Background()
+
+	key, token, err := svc.CreateKey(ctx, CreateAPIKeyInput{Name: "ci", Scopes: []string{model.ScopeLinksRead}})
+	if err != nil {
+		t.Fatalf("CreateKey error: %v", err)
+	}
+	if !strings.HasPrefix(token, key.Prefix) {
+		t.Fatalf("expected token %q to start with prefix %q", token, key.Prefix)
+	}
+	if key.KeyHash == token || key.KeyHash != HashAPIKey(token) {
+		t.Fatalf("expected only the hash of the token to be stored")
+	}
+
+	got, err := svc.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if !got.HasScope(model.ScopeLinksRead) || got.HasScope(model.ScopeLinksWrite) {
+		t.Fatalf("unexpected scopes %v", got.Scopes)
+	}
+
+	if _, err := svc.Authenticate(ctx, token+"x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected ErrInvalidAPIKey for unknown token, got %v", err)
+	}
+
+	if err := svc.RevokeKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeKey error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, token); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected ErrInvalidAPIKey for revoked key, got %v", err)
+	}
+}
+
+func TestAPIKeyService_CreateKey_RejectsUnknownScope(t *testing.T) {
+	svc := NewAPIKeyService(newMemoryAPIKeyRepository(), nil, nil)
+
+	_, _, err := svc.CreateKey(context.Background(), CreateAPIKeyInput{Name: "bad", Scopes: []string{"links:delete"}})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestAPIKeyService_EnsureKey_Idempotent(t *testing.T) {
+	repo := newMemoryAPIKeyRepository()
+	svc := NewAPIKeyService(repo, nil, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := svc.EnsureKey(ctx, "bootstrap", "pu_bootstrap-secret", []string{model.ScopeAdmin}); err != nil {
+			t.Fatalf("EnsureKey error: %v", err)
+		}
+	}
+	if len(repo.keys) != 1 {
+		t.Fatalf("expected a single key, got %d", len(repo.keys))
+	}
+
+	key, err := svc.Authenticate(ctx, "pu_bootstrap-secret")
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if !key.HasScope(model.ScopeLinksWrite) {
+		t.Fatalf("expected admin key to imply links:write")
+	}
+}
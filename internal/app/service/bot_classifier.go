@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+// maxBotReasonLength matches the bot_reason column of click events.
+const maxBotReasonLength = 255
+
+// DefaultBotUserAgents are User-Agent substrings of crawlers, scanners, HTTP
+// libraries, headless browsers, monitoring services and link previewers.
+var DefaultBotUserAgents = []string{
+	"bot", "crawler", "spider", "slurp",
+	"nmap", "masscan", "zgrab", "censysinspect", "nuclei", "nikto", "sqlmap",
+	"curl/", "wget/", "httpie/", "python-requests", "python-urllib", "aiohttp",
+	"go-http-client", "java/", "okhttp", "apache-httpclient", "libwww-perl", "node-fetch", "axios/",
+	"headlesschrome", "phantomjs", "puppeteer", "playwright",
+	"uptime", "pingdom", "statuscake", "site24x7", "newrelicpinger", "datadog/synthetics", "hetrixtools", "freshping",
+	"facebookexternalhit", "whatsapp", "skypeuripreview", "embedly", "iframely",
+	"bingpreview", "google web preview", "snap url preview", "yahoo link preview",
+}
+
+// DatacenterRanges tells whether an IP belongs to a hosting or cloud network.
+type DatacenterRanges interface {
+	// Match returns why ip is listed, or "" when it is not.
+	Match(ip string) string
+}
+
+// ClickSignals are the request headers a click is classified by besides its IP
+// and User-Agent.
+type ClickSignals struct {
+	Accept         string
+	AcceptLanguage string
+}
+
+// BotClassifierDeps groups the collaborators and settings of the bot classifier.
+type BotClassifierDeps struct {
+	Logger      *zap.Logger
+	UserAgents  []string         // patterns on top of DefaultBotUserAgents
+	Datacenters DatacenterRanges // optional
+	// Rates counts clicks per IP; past RateLimit clicks in its window an IP counts
+	// as automated. Optional.
+	Rates     repository.ClickRateCounter
+	RateLimit int
+}
+
+// BotClassifier recognises automated traffic among clicks: known bot User-Agents,
+// requests lacking the headers every browser sends, datacenter addresses and
+// IPs clicking faster than a person would.
+type BotClassifier struct {
+	logger      *zap.Logger
+	patterns    []string
+	datacenters DatacenterRanges
+	rates       repository.ClickRateCounter
+	rateLimit   int64
+}
+
+// NewBotClassifier creates a bot classifier.
+func NewBotClassifier(deps BotClassifierDeps) *BotClassifier {
+	b := &BotClassifier{
+		logger:      deps.Logger,
+		datacenters: deps.Datacenters,
+		rates:       deps.Rates,
+		rateLimit:   int64(deps.RateLimit),
+	}
+	if b.logger == nil {
+		b.logger = zap.NewNop()
+	}
+	for _, p := range slices.Concat(DefaultBotUserAgents, deps.UserAgents) {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			b.patterns = append(b.patterns, p)
+		}
+	}
+	if b.rateLimit <= 0 {
+		b.rates = nil
+	}
+	return b
+}
+
+// Classify returns why a click from ip with userAgent looks automated, or "" when
+// it looks like a person. Call it once per click: every call counts against the
+// IP's click rate.
+func (b *BotClassifier) Classify(ctx context.Context, ip, userAgent string, signals ClickSignals) string {
+	if b == nil {
+		return ""
+	}
+	return truncate(b.classify(ctx, ip, userAgent, signals), maxBotReasonLength)
+}
+
+func (b *BotClassifier) classify(ctx context.Context, ip, userAgent string, signals ClickSignals) string {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return "missing User-Agent header"
+	}
+	for _, p := range b.patterns {
+		if strings.Contains(ua, p) {
+			return fmt.Sprintf("User-Agent matches %q", p)
+		}
+	}
+	// Browsers send Accept-Language and a specific Accept on every navigation.
+	if accept := strings.TrimSpace(signals.Accept); strings.TrimSpace(signals.AcceptLanguage) == "" && (accept == "" || accept == "*/*") {
+		return "missing browser headers"
+	}
+	if b.datacenters != nil {
+		if reason := b.datacenters.Match(ip); reason != "" {
+			return "datacenter IP: " + reason
+		}
+	}
+	if b.rates != nil {
+		clicks, err := b.rates.Hit(ctx, ip)
+		if err != nil {
+			b.logger.Warn("failed to count clicks per IP", zap.Error(err), zap.String("ip", ip))
+			return ""
+		}
+		if clicks > b.rateLimit {
+			return fmt.Sprintf("%d clicks from this IP within the rate window", clicks)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubDatacenters lists IPs starting with any of its prefixes.
+type stubDatacenters []string
+
+func (d stubDatacenters) Match(ip string) string {
+	for _, prefix := range d {
+		if strings.HasPrefix(ip, prefix) {
+			return "listed in stub"
+		}
+	}
+	return ""
+}
+
+type stubClickRates struct {
+	hits map[string]int64
+	err  error
+}
+
+func (r *stubClickRates) Hit(ctx context.Context, ip string) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.hits[ip]++
+	return r.hits[ip], nil
+}
+
+func TestBotClassifier_Classify(t *testing.T) {
+	const browser = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/124.0 Safari/537.36"
+	browserHeaders := ClickSignals{Accept: "text/html,application/xhtml+xml", AcceptLanguage: "en-US,en;q=0.9"}
+
+	rates := &stubClickRates{hits: map[string]int64{}}
+	classifier := NewBotClassifier(BotClassifierDeps{
+		UserAgents:  []string{"AcmeChecker"},
+		Datacenters: stubDatacenters{"203.0.113."},
+		Rates:       rates,
+		RateLimit:   2,
+	})
+
+	cases := []struct {
+		name      string
+		ip        string
+		userAgent string
+		signals   ClickSignals
+		want      string
+	}{
+		{"browser", "198.51.100.1", browser, browserHeaders, ""},
+		{"crawler", "198.51.100.2", "Googlebot/2.1 (+http://www.google.com/bot.html)", browserHeaders, `User-Agent matches "bot"`},
+		{"scanner", "198.51.100.2", "Mozilla/5.0 zgrab/0.x", browserHeaders, `User-Agent matches "zgrab"`},
+		{"monitor", "198.51.100.2", "NewRelicPinger/1.0 (12345)", browserHeaders, `User-Agent matches "newrelicpinger"`},
+		{"previewer", "198.51.100.2", "Mozilla/5.0 (compatible; Google Web Preview)", browserHeaders, `User-Agent matches "google web preview"`},
+		{"app browser with a generic word", "198.51.100.2", browser + " ScanApp/4.2 MonitorPlus Preview", browserHeaders, ""},
+		{"custom pattern", "198.51.100.3", "acmechecker/3", browserHeaders, `User-Agent matches "acmechecker"`},
+		{"no user agent", "198.51.100.4", "", browserHeaders, "missing User-Agent header"},
+		{"no browser headers", "198.51.100.5", browser, ClickSignals{Accept: "*/*"}, "missing browser headers"},
+		{"datacenter", "203.0.113.9", browser, browserHeaders, "datacenter IP: listed in stub"},
+	}
+	for _, tc := range cases {
+		if got := classifier.Classify(context.Background(), tc.ip, tc.userAgent, tc.signals); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := classifier.Classify(context.Background(), "192.0.2.7", browser, browserHeaders); got != "" {
+			t.Fatalf("expected click %d to be human, got %q", i+1, got)
+		}
+	}
+	if got := classifier.Classify(context.Background(), "192.0.2.7", browser, browserHeaders); !strings.Contains(got, "3 clicks") {
+		t.Fatalf("expected the third click within the window to be a bot, got %q", got)
+	}
+
+	rates.err = errors.New("redis down")
+	if got := classifier.Classify(context.Background(), "192.0.2.8", browser, browserHeaders); got != "" {
+		t.Fatalf("expected a counter failure to leave the click human, got %q", got)
+	}
+}
+
+func TestBotClassifier_ClassifyTruncatesReason(t *testing.T) {
+	pattern := strings.Repeat("x", 2*maxBotReasonLength)
+	classifier := NewBotClassifier(BotClassifierDeps{UserAgents: []string{pattern}})
+
+	got := classifier.Classify(context.Background(), "198.51.100.1", "agent-"+pattern, ClickSignals{})
+	if got == "" || len(got) > maxBotReasonLength {
+		t.Fatalf("expected a reason of at most %d bytes, got %d", maxBotReasonLength, len(got))
+	}
+
+	var disabled *BotClassifier
+	if got := disabled.Classify(context.Background(), "198.51.100.1", "curl/8.5.0", ClickSignals{}); got != "" {
+		t.Fatalf("expected no classification without a classifier, got %q", got)
+	}
+}
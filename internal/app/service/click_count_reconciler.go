@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apprepository "github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+const clickCountFlushBatch = 500
+
+// ClickCountReconciler periodically writes live click counters from Redis back to Postgres.
+type ClickCountReconciler struct {
+	logger   *zap.Logger
+	counter  apprepository.ClickCounter
+	interval time.Duration
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// NewClickCountReconciler creates a reconciler flushing counters every interval.
+func NewClickCountReconciler(logger *zap.Logger, counter apprepository.ClickCounter, interval time.Duration) *ClickCountReconciler {
+	return &ClickCountReconciler{
+		logger:   logger,
+		counter:  counter,
+		interval: interval,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins the periodic reconciliation.
+func (r *ClickCountReconciler) Start() {
+	go r.run()
+}
+
+// Stop flushes outstanding counters once more and stops the reconciler.
+func (r *ClickCountReconciler) Stop() {
+	close(r.stopChan)
+	<-r.done
+}
+
+func (r *ClickCountReconciler) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+		case <-r.stopChan:
+			r.flush()
+			r.logger.Info("click count reconciler stopped")
+			return
+		}
+	}
+}
+
+func (r *ClickCountReconciler) flush() {
+	ctx := context.Background()
+	for {
+		written, err := r.counter.Flush(ctx, clickCountFlushBatch)
+		if err != nil {
+			r.logger.Error("failed to reconcile click counters", zap.Error(err))
+			return
+		}
+		if written > 0 {
+			r.logger.Debug("reconciled click counters", zap.Int("count", written))
+		}
+		if written < clickCountFlushBatch {
+			return
+		}
+	}
+}
@@ -12,32 +12,51 @@ import (
 
 // ClickPublisher publishes click events to NATS JetStream
 type ClickPublisher struct {
-	js nats.JetStreamContext
+	js   nats.JetStreamContext
+	bots *BotClassifier
 }
 
-// NewClickPublisher creates a new click event publisher
-func NewClickPublisher(js nats.JetStreamContext) *ClickPublisher {
-	return &ClickPublisher{js: js}
+// NewClickPublisher creates a new click event publisher; bots classifies the clicks
+// of the events it builds and may be nil to treat every click as human
+func NewClickPublisher(js nats.JetStreamContext, bots *BotClassifier) *ClickPublisher {
+	return &ClickPublisher{js: js, bots: bots}
 }
 
-// Publish publishes a click event to the stream
-func (p *ClickPublisher) Publish(linkCode, ip, userAgent, status, clickID string) error {
-	return p.PublishWithContext(context.Background(), linkCode, ip, userAgent, status, clickID)
-}
-
-// PublishWithContext publishes a click event to the stream with context timeout
-func (p *ClickPublisher) PublishWithContext(ctx context.Context, linkCode, ip, userAgent, status, clickID string) error {
-	eventID := clickID
-	if eventID == "" {
-		eventID = uuid.New().String()
-	}
+// NewEvent builds the event of a click and classifies it as a bot or a person.
+// Build one event per click: every classification counts against the IP's click rate
+func (p *ClickPublisher) NewEvent(ctx context.Context, linkCode, ip, userAgent, status string, signals ClickSignals) model.ClickEvent {
 	event := model.ClickEvent{
-		ID:        eventID,
 		LinkCode:  linkCode,
 		IP:        ip,
 		UserAgent: userAgent,
 		Status:    status,
-		Timestamp: time.Now(),
+	}
+	if p != nil {
+		event.BotReason = p.bots.Classify(ctx, ip, userAgent, signals)
+		event.IsBot = event.BotReason != ""
+	}
+	return event
+}
+
+// Publish publishes a click event to the stream
+func (p *ClickPublisher) Publish(linkCode, ip, userAgent, status, clickID string, signals ClickSignals) error {
+	return p.PublishWithContext(context.Background(), linkCode, ip, userAgent, status, clickID, signals)
+}
+
+// PublishWithContext publishes a click event to the stream with context timeout
+func (p *ClickPublisher) PublishWithContext(ctx context.Context, linkCode, ip, userAgent, status, clickID string, signals ClickSignals) error {
+	event := p.NewEvent(ctx, linkCode, ip, userAgent, status, signals)
+	event.ID = clickID
+	return p.PublishEvent(ctx, event)
+}
+
+// PublishEvent publishes a click event built by NewEvent, filling in a missing ID and timestamp
+func (p *ClickPublisher) PublishEvent(ctx context.Context, event model.ClickEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
 	}
 
 	data, err := json.Marshal(event)
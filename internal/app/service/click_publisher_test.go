@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+// recordingJetStream keeps published click events; other methods are not used by these tests.
+type recordingJetStream struct {
+	nats.JetStreamContext
+	events []model.ClickEvent
+}
+
+func (js *recordingJetStream) Publish(subj string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	var event model.ClickEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	js.events = append(js.events, event)
+	return &nats.PubAck{}, nil
+}
+
+func TestClickPublisher_ClassifiesEveryEvent(t *testing.T) {
+	const browser = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/124.0 Safari/537.36"
+	browserHeaders := ClickSignals{Accept: "text/html", AcceptLanguage: "en"}
+
+	js := &recordingJetStream{}
+	publisher := NewClickPublisher(js, NewBotClassifier(BotClassifierDeps{}))
+
+	if err := publisher.PublishWithContext(context.Background(), "promo", "198.51.100.1", "curl/8.5.0", model.ClickStatusSuccess, "c1", browserHeaders); err != nil {
+		t.Fatalf("PublishWithContext error: %v", err)
+	}
+	if err := publisher.Publish("promo", "198.51.100.1", browser, model.ClickStatusSuccess, "c2", browserHeaders); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	event := publisher.NewEvent(context.Background(), "promo", "198.51.100.1", browser, model.ClickStatusPending, ClickSignals{})
+	if err := publisher.PublishEvent(context.Background(), event); err != nil {
+		t.Fatalf("PublishEvent error: %v", err)
+	}
+
+	want := []struct {
+		id     string
+		reason string
+	}{
+		{"c1", `User-Agent matches "curl/"`},
+		{"c2", ""},
+		{"", "missing browser headers"},
+	}
+	if len(js.events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(js.events))
+	}
+	for i, w := range want {
+		got := js.events[i]
+		if (w.id != "" && got.ID != w.id) || got.BotReason != w.reason || got.IsBot != (w.reason != "") {
+			t.Errorf("event %d: expected id %q and bot reason %q, got %+v", i, w.id, w.reason, got)
+		}
+	}
+
+	var unclassified *ClickPublisher
+	if event := unclassified.NewEvent(context.Background(), "promo", "198.51.100.1", "curl/8.5.0", model.ClickStatusPending, ClickSignals{}); event.IsBot {
+		t.Fatal("expected a nil publisher to treat the click as human")
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sifan077/PowerURL/internal/app/repository"
+)
+
+// ClickService exposes read access to recorded click events.
+type ClickService interface {
+	ListClicks(ctx context.Context, query repository.ClickQuery) (*repository.ClickPage, error)
+	VariantStats(ctx context.Context, linkCode string, includeBots bool) ([]repository.VariantStats, error)
+}
+
+type clickService struct {
+	repo repository.ClickEventRepository
+}
+
+// NewClickService returns a ClickService backed by the given repository.
+func NewClickService(repo repository.ClickEventRepository) ClickService {
+	return &clickService{repo: repo}
+}
+
+func (s *clickService) ListClicks(ctx context.Context, query repository.ClickQuery) (*repository.ClickPage, error) {
+	page, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list clicks: %w", err)
+	}
+	return page, nil
+}
+
+func (s *clickService) VariantStats(ctx context.Context, linkCode string, includeBots bool) ([]repository.VariantStats, error) {
+	stats, err := s.repo.VariantStats(ctx, linkCode, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("variant stats: %w", err)
+	}
+	return stats, nil
+}
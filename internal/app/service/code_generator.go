@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"os"
+	"strings"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+const (
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	CodeStrategyRandom   = "random"
+	CodeStrategySequence = "sequence"
+	CodeStrategyWordlist = "wordlist"
+
+	defaultCodeLength      = 7
+	defaultCodeMaxAttempts = 5
+	defaultWordCount       = 2
+	defaultWordSeparator   = "-"
+
+	// sequenceMultiplier is coprime with 62 so multiplying by it permutes [0, 62^n).
+	sequenceMultiplier = 1580030173
+)
+
+// CodeGenerator produces candidate short codes. Candidates may collide with
+// existing links; callers are expected to retry on conflict.
+type CodeGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// SequenceSource hands out monotonically increasing IDs (e.g. a Postgres sequence).
+type SequenceSource interface {
+	NextCodeSequence(ctx context.Context) (int64, error)
+}
+
+// NewCodeGenerator builds the generator selected by cfg.Strategy.
+func NewCodeGenerator(cfg config.CodeGenConfig, seq SequenceSource) (CodeGenerator, error) {
+	switch strings.ToLower(cfg.Strategy) {
+	case "", CodeStrategyRandom:
+		return NewRandomCodeGenerator(cfg.Length), nil
+	case CodeStrategySequence:
+		if seq == nil {
+			return nil, errors.New("codegen: sequence strategy requires a sequence source")
+		}
+		return NewSequenceCodeGenerator(seq, cfg.Length, cfg.SequenceSalt), nil
+	case CodeStrategyWordlist:
+		words := defaultWordlist
+		if cfg.WordlistFile != "" {
+			loaded, err := LoadWordlist(cfg.WordlistFile)
+			if err != nil {
+				return nil, fmt.Errorf("codegen: %w", err)
+			}
+			words = loaded
+		}
+		return NewWordlistCodeGenerator(words, cfg.WordCount, cfg.Separator)
+	default:
+		return nil, fmt.Errorf("codegen: unknown strategy %q", cfg.Strategy)
+	}
+}
+
+// RandomCodeGenerator emits uniformly random base62 strings.
+type RandomCodeGenerator struct {
+	length int
+}
+
+// NewRandomCodeGenerator returns a generator producing base62 codes of the given length.
+func NewRandomCodeGenerator(length int) *RandomCodeGenerator {
+	if length <= 0 {
+		length = defaultCodeLength
+	}
+	return &RandomCodeGenerator{length: length}
+}
+
+func (g *RandomCodeGenerator) Generate(ctx context.Context) (string, error) {
+	// 248 is the largest multiple of 62 below 256; rejecting bytes above it keeps the distribution uniform.
+	const limit = 248
+	out := make([]byte, 0, g.length)
+	buf := make([]byte, g.length*2)
+	for len(out) < g.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("codegen: read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if b >= limit {
+				continue
+			}
+			out = append(out, base62Alphabet[int(b)%len(base62Alphabet)])
+			if len(out) == g.length {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
+
+// SequenceCodeGenerator maps sequence values onto fixed-length base62 codes
+// through a bijection, so consecutive IDs do not yield guessable neighbours.
+// Codes only repeat once the sequence exceeds 62^length.
+type SequenceCodeGenerator struct {
+	seq     SequenceSource
+	length  int
+	modulus uint64
+	salt    uint64
+}
+
+// NewSequenceCodeGenerator returns a generator backed by the given sequence source.
+func NewSequenceCodeGenerator(seq SequenceSource, length int, salt uint64) *SequenceCodeGenerator {
+	if length <= 0 {
+		length = defaultCodeLength
+	}
+	// 62^10 is the largest power that fits in a uint64.
+	if length > 10 {
+		length = 10
+	}
+	modulus := uint64(1)
+	for i := 0; i < length; i++ {
+		modulus *= uint64(len(base62Alphabet))
+	}
+	return &SequenceCodeGenerator{
+		seq:     seq,
+		length:  length,
+		modulus: modulus,
+		salt:    salt % modulus,
+	}
+}
+
+func (g *SequenceCodeGenerator) Generate(ctx context.Context) (string, error) {
+	id, err := g.seq.NextCodeSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("codegen: next sequence value: %w", err)
+	}
+	return g.Encode(uint64(id)), nil
+}
+
+// Encode obfuscates id and renders it as a zero-padded base62 string.
+func (g *SequenceCodeGenerator) Encode(id uint64) string {
+	hi, lo := bits.Mul64(id%g.modulus, sequenceMultiplier)
+	value := bits.Rem64(hi, lo, g.modulus)
+	value = (value + g.salt) % g.modulus
+
+	out := make([]byte, g.length)
+	base := uint64(len(base62Alphabet))
+	for i := g.length - 1; i >= 0; i-- {
+		out[i] = base62Alphabet[value%base]
+		value /= base
+	}
+	return string(out)
+}
+
+// WordlistCodeGenerator builds human-readable codes such as "brave-otter".
+type WordlistCodeGenerator struct {
+	words     []string
+	count     int
+	separator string
+}
+
+// NewWordlistCodeGenerator returns a generator that joins count random words.
+func NewWordlistCodeGenerator(words []string, count int, separator string) (*WordlistCodeGenerator, error) {
+	if len(words) == 0 {
+		return nil, errors.New("codegen: wordlist is empty")
+	}
+	if count <= 0 {
+		count = defaultWordCount
+	}
+	if separator == "" {
+		separator = defaultWordSeparator
+	}
+	return &WordlistCodeGenerator{words: words, count: count, separator: separator}, nil
+}
+
+func (g *WordlistCodeGenerator) Generate(ctx context.Context) (string, error) {
+	parts := make([]string, g.count)
+	total := big.NewInt(int64(len(g.words)))
+	for i := range parts {
+		n, err := rand.Int(rand.Reader, total)
+		if err != nil {
+			return "", fmt.Errorf("codegen: pick word: %w", err)
+		}
+		parts[i] = g.words[n.Int64()]
+	}
+	return strings.Join(parts, g.separator), nil
+}
+
+// LoadWordlist reads one word per line, skipping blanks and '#' comments.
+func LoadWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open wordlist: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read wordlist: %w", err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("wordlist %s is empty", path)
+	}
+	return words, nil
+}
+
+var defaultWordlist = []string{
+	"amber", "apple", "arrow", "aspen", "atlas", "autumn", "badge", "bamboo",
+	"basil", "beacon", "birch", "blaze", "bloom", "brave", "breeze", "brook",
+	"cactus", "canyon", "cedar", "chalk", "cherry", "cider", "clever", "cloud",
+	"clover", "cobalt", "comet", "coral", "cosmic", "crisp", "daisy", "dawn",
+	"delta", "dune", "eager", "echo", "ember", "falcon", "fern", "fig",
+	"flint", "forest", "frost", "gentle", "ginger", "glade", "golden", "granite",
+	"harbor", "hazel", "honey", "indigo", "iris", "ivory", "jade", "jolly",
+	"juniper", "kelp", "kind", "lagoon", "lemon", "lilac", "lively", "lotus",
+	"lucky", "lunar", "maple", "marble", "meadow", "mellow", "mint", "misty",
+	"nimble", "noble", "oasis", "ocean", "olive", "onyx", "orbit", "otter",
+	"pebble", "pepper", "pine", "planet", "plum", "polar", "prairie", "quartz",
+	"quiet", "rapid", "raven", "ripple", "river", "robin", "rustic", "saffron",
+	"sage", "sandy", "silver", "solar", "spruce", "stone", "sunny", "swift",
+	"thistle", "tidal", "tiger", "topaz", "tulip", "velvet", "violet", "willow",
+}
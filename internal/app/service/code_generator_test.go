@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+func TestRandomCodeGenerator(t *testing.T) {
+	gen := NewRandomCodeGenerator(9)
+	for i := 0; i < 100; i++ {
+		code, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate error: %v", err)
+		}
+		if len(code) != 9 {
+			t.Fatalf("expected length 9, got %q", code)
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(base62Alphabet, r) {
+				t.Fatalf("unexpected character %q in %q", r, code)
+			}
+		}
+	}
+}
+
+func TestSequenceCodeGenerator_Unique(t *testing.T) {
+	var next int64
+	repo := &mockLinkRepository{
+		seqFn: func(ctx context.Context) (int64, error) {
+			next++
+			return next, nil
+		},
+	}
+	gen := NewSequenceCodeGenerator(repo, 3, 42)
+
+	seen := make(map[string]int64)
+	for i := 0; i < 5000; i++ {
+		code, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate error: %v", err)
+		}
+		if len(code) != 3 {
+			t.Fatalf("expected length 3, got %q", code)
+		}
+		if prev, ok := seen[code]; ok {
+			t.Fatalf("code %q produced by ids %d and %d", code, prev, next)
+		}
+		seen[code] = next
+	}
+}
+
+func TestWordlistCodeGenerator(t *testing.T) {
+	gen, err := NewWordlistCodeGenerator([]string{"alpha", "beta"}, 3, "_")
+	if err != nil {
+		t.Fatalf("NewWordlistCodeGenerator error: %v", err)
+	}
+	code, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	parts := strings.Split(code, "_")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 words, got %q", code)
+	}
+	for _, p := range parts {
+		if p != "alpha" && p != "beta" {
+			t.Fatalf("unexpected word %q in %q", p, code)
+		}
+	}
+}
+
+func TestNewCodeGenerator_UnknownStrategy(t *testing.T) {
+	if _, err := NewCodeGenerator(config.CodeGenConfig{Strategy: "nope"}, nil); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
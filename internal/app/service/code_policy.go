@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+const (
+	defaultCodeAlphabet  = base62Alphabet + "-_"
+	defaultCodeMinLength = 3
+	// maxCodeColumnLength mirrors the size of the links.code column.
+	maxCodeColumnLength = 32
+)
+
+// FieldError describes a validation failure on a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError aggregates field-level validation failures.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// CodePolicy decides whether a short code may be assigned to a link.
+type CodePolicy struct {
+	alphabet  string
+	minLength int
+	maxLength int
+	profanity []string
+
+	mu       sync.RWMutex
+	reserved map[string]struct{}
+}
+
+// NewCodePolicy builds a policy from configuration, loading the profanity list if configured.
+func NewCodePolicy(cfg config.CodePolicyConfig) (*CodePolicy, error) {
+	p := &CodePolicy{
+		alphabet:  cfg.Alphabet,
+		minLength: cfg.MinLength,
+		maxLength: cfg.MaxLength,
+		reserved:  make(map[string]struct{}),
+	}
+	if p.alphabet == "" {
+		p.alphabet = defaultCodeAlphabet
+	}
+	if p.minLength <= 0 {
+		p.minLength = defaultCodeMinLength
+	}
+	if p.maxLength <= 0 || p.maxLength > maxCodeColumnLength {
+		p.maxLength = maxCodeColumnLength
+	}
+	if p.minLength > p.maxLength {
+		return nil, fmt.Errorf("code policy: min_length %d exceeds max_length %d", p.minLength, p.maxLength)
+	}
+	if strings.ContainsRune(p.alphabet, '/') {
+		return nil, fmt.Errorf("code policy: alphabet must not contain '/'")
+	}
+
+	if cfg.ProfanityFile != "" {
+		words, err := LoadWordlist(cfg.ProfanityFile)
+		if err != nil {
+			return nil, fmt.Errorf("code policy: %w", err)
+		}
+		p.profanity = words
+	}
+
+	p.Reserve(cfg.Reserved...)
+	return p, nil
+}
+
+// Reserve marks additional words as unavailable. Matching is case-insensitive.
+func (p *CodePolicy) Reserve(words ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w != "" {
+			p.reserved[w] = struct{}{}
+		}
+	}
+}
+
+// IsReserved reports whether code collides with a reserved word.
+func (p *CodePolicy) IsReserved(code string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.reserved[strings.ToLower(code)]
+	return ok
+}
+
+// Validate returns a FieldError for the "code" field when code violates the policy.
+func (p *CodePolicy) Validate(code string) *FieldError {
+	invalid := func(format string, args ...interface{}) *FieldError {
+		return &FieldError{Field: "code", Message: fmt.Sprintf(format, args...)}
+	}
+
+	length := len([]rune(code))
+	if length < p.minLength || length > p.maxLength {
+		return invalid("must be between %d and %d characters", p.minLength, p.maxLength)
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(p.alphabet, r) {
+			return invalid("contains unsupported character %q", r)
+		}
+	}
+	if p.IsReserved(code) {
+		return invalid("%q is reserved", code)
+	}
+
+	lower := strings.ToLower(code)
+	for _, word := range p.profanity {
+		if strings.Contains(lower, word) {
+			return invalid("is not allowed")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+func TestCodePolicy_Validate(t *testing.T) {
+	dir := t.TempDir()
+	profanity := filepath.Join(dir, "profanity.txt")
+	if err := os.WriteFile(profanity, []byte("# comment\nbadword\n"), 0o600); err != nil {
+		t.Fatalf("write profanity file: %v", err)
+	}
+
+	policy, err := NewCodePolicy(config.CodePolicyConfig{
+		MinLength:     3,
+		MaxLength:     10,
+		Reserved:      []string{"Admin"},
+		ProfanityFile: profanity,
+	})
+	if err != nil {
+		t.Fatalf("NewCodePolicy error: %v", err)
+	}
+	policy.Reserve("api", "health")
+
+	cases := []struct {
+		code  string
+		valid bool
+	}{
+		{"promo-2024", true},
+		{"ab", false},
+		{"abcdefghijk", false},
+		{"with/slash", false},
+		{"sp ace", false},
+		{"API", false},
+		{"admin", false},
+		{"xxBadWordxx", false},
+	}
+	for _, tc := range cases {
+		got := policy.Validate(tc.code)
+		if tc.valid && got != nil {
+			t.Errorf("expected %q to be valid, got %s", tc.code, got.Message)
+		}
+		if !tc.valid && got == nil {
+			t.Errorf("expected %q to be rejected", tc.code)
+		}
+	}
+}
+
+func TestLinkService_CreateLink_RejectsReservedCode(t *testing.T) {
+	policy, err := NewCodePolicy(config.CodePolicyConfig{})
+	if err != nil {
+		t.Fatalf("NewCodePolicy error: %v", err)
+	}
+	policy.Reserve("api")
+
+	repo := &mockLinkRepository{
+		createFn: func(ctx context.Context, link *model.Link) error {
+			t.Fatal("repository should not be called for an invalid code")
+			return nil
+		},
+	}
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, CodePolicy: policy})
+
+	_, err = svc.CreateLink(context.Background(), CreateLinkInput{Code: "api", URL: "https://example.com"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "code" {
+		t.Fatalf("expected a single code field error, got %+v", validationErr.Fields)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+// Query policies decide what happens to query parameters a visitor appends to a short link.
+const (
+	// QueryPolicyIgnore drops them; the destination is used as configured.
+	QueryPolicyIgnore = "ignore"
+	// QueryPolicyMerge adds parameters the destination does not set itself.
+	QueryPolicyMerge = "merge"
+	// QueryPolicyOverride adds all of them, replacing destination parameters of the same name.
+	QueryPolicyOverride = "override"
+)
+
+var queryPolicies = []string{QueryPolicyIgnore, QueryPolicyMerge, QueryPolicyOverride}
+
+// placeholderPattern matches destination placeholders such as {code} or {query.utm_source}.
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)(?:\.([A-Za-z0-9_.\-\[\]]+))?\}`)
+
+// Placeholders expanded in destinations, besides {query.<name>}.
+var templatePlaceholders = []string{"click_id", "code", "country", "variant"}
+
+// TemplateVars are the values destination placeholders expand to.
+type TemplateVars struct {
+	ClickID string
+	Code    string
+	Country string
+	Variant string
+	// Query holds the visitor's query parameters for {query.<name>}.
+	Query url.Values
+}
+
+func (v TemplateVars) lookup(name, param string) (string, bool) {
+	switch name {
+	case "click_id":
+		return v.ClickID, param == ""
+	case "code":
+		return v.Code, param == ""
+	case "country":
+		return v.Country, param == ""
+	case "variant":
+		return v.Variant, param == ""
+	case "query":
+		return v.Query.Get(param), param != ""
+	}
+	return "", false
+}
+
+// ExpandDestination fills the placeholders of a destination template. Values are
+// percent-encoded down to unreserved characters, and placeholders are only expanded
+// after the host, so no value can change where the destination points to.
+// Unknown placeholders are left as they are.
+func ExpandDestination(raw string, vars TemplateVars) string {
+	if !strings.Contains(raw, "{") {
+		return raw
+	}
+
+	start := authorityEnd(raw)
+	return raw[:start] + placeholderPattern.ReplaceAllStringFunc(raw[start:], func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		value, ok := vars.lookup(parts[1], parts[2])
+		if !ok {
+			return match
+		}
+		return escapeStrict(value)
+	})
+}
+
+// ApplyQueryPolicy adds the visitor's raw query string to destination according to
+// policy. The destination's own query is kept as written.
+func ApplyQueryPolicy(destination, policy, rawQuery string) string {
+	if rawQuery == "" || policy == "" || policy == QueryPolicyIgnore {
+		return destination
+	}
+	incoming, err := url.ParseQuery(rawQuery)
+	if err != nil || len(incoming) == 0 {
+		return destination
+	}
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+
+	existing := u.Query()
+	var pairs []string
+	if u.RawQuery != "" {
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			key, _, _ := strings.Cut(pair, "=")
+			if name, err := url.QueryUnescape(key); err == nil && policy == QueryPolicyOverride && incoming.Has(name) {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil || name == "" {
+			continue
+		}
+		if policy == QueryPolicyMerge && existing.Has(name) {
+			continue
+		}
+		pairs = append(pairs, encodeQueryPair(pair))
+	}
+
+	u.RawQuery = strings.Join(pairs, "&")
+	u.ForceQuery = false
+	return u.String()
+}
+
+// encodeQueryPair re-encodes a visitor's key=value pair so it stays one parameter.
+func encodeQueryPair(pair string) string {
+	key, value, hasValue := strings.Cut(pair, "=")
+	key, _ = url.QueryUnescape(key)
+	value, _ = url.QueryUnescape(value)
+	if !hasValue {
+		return url.QueryEscape(key)
+	}
+	return url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
+// validateDestinationOptions checks the query policy and URL template of link.
+func validateDestinationOptions(link *model.Link) *FieldError {
+	if link.QueryPolicy != "" && !slices.Contains(queryPolicies, link.QueryPolicy) {
+		return &FieldError{Field: "query_policy", Message: "must be one of: ignore, merge, override"}
+	}
+	if msg := validateDestinationTemplate(link.URL); msg != "" {
+		return &FieldError{Field: "url", Message: msg}
+	}
+	return nil
+}
+
+// validateDestinationTemplate returns why the placeholders of raw are unusable, or "".
+func validateDestinationTemplate(raw string) string {
+	if !strings.Contains(raw, "{") {
+		return ""
+	}
+
+	start := authorityEnd(raw)
+	if placeholderPattern.MatchString(raw[:start]) {
+		return "must not use placeholders in the scheme or host"
+	}
+	for _, parts := range placeholderPattern.FindAllStringSubmatch(raw[start:], -1) {
+		if _, ok := (TemplateVars{}).lookup(parts[1], parts[2]); !ok {
+			return fmt.Sprintf("has unknown placeholder %s; use {%s} or {query.<name>}", parts[0], strings.Join(templatePlaceholders, "}, {"))
+		}
+	}
+	return ""
+}
+
+// authorityEnd returns the index where the path, query or fragment of raw begins.
+func authorityEnd(raw string) int {
+	rest := 0
+	if i := strings.Index(raw, "://"); i >= 0 {
+		rest = i + len("://")
+	}
+	if i := strings.IndexAny(raw[rest:], "/?#"); i >= 0 {
+		return rest + i
+	}
+	return len(raw)
+}
+
+// escapeStrict percent-encodes everything but RFC 3986 unreserved characters.
+func escapeStrict(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
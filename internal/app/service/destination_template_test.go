@@ -0,0 +1,61 @@
+package service
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestExpandDestination(t *testing.T) {
+	vars := TemplateVars{
+		ClickID: "c1",
+		Code:    "abc",
+		Country: "DE",
+		Query:   url.Values{"utm_source": {"//evil.com/@x?y#z"}},
+	}
+
+	got := ExpandDestination("https://example.com/{code}/{country}?cid={click_id}&src={query.utm_source}&m={query.missing}", vars)
+	want := "https://example.com/abc/DE?cid=c1&src=%2F%2Fevil.com%2F%40x%3Fy%23z&m="
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if got := ExpandDestination("https://{code}.example.com/{unknown}", vars); got != "https://{code}.example.com/{unknown}" {
+		t.Fatalf("expected host and unknown placeholders to stay unexpanded, got %q", got)
+	}
+}
+
+func TestValidateDestinationTemplate(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.com/landing":                  true,
+		"https://example.com/{code}?src={query.utm_x}": true,
+		"https://example.com?v={variant}":              true,
+		"https://{country}.example.com/":               false,
+		"https://example.com{code}":                    false,
+		"https://example.com/{secret}":                 false,
+		"https://example.com/{query}":                  false,
+	}
+	for raw, valid := range cases {
+		if got := validateDestinationTemplate(raw) == ""; got != valid {
+			t.Errorf("%s: expected valid=%v", raw, valid)
+		}
+	}
+}
+
+func TestApplyQueryPolicy(t *testing.T) {
+	const destination = "https://example.com/p?utm_source=site&x=1#top"
+	const incoming = "utm_source=mail&ref=a%26b"
+
+	cases := []struct {
+		policy string
+		want   string
+	}{
+		{QueryPolicyIgnore, destination},
+		{QueryPolicyMerge, "https://example.com/p?utm_source=site&x=1&ref=a%26b#top"},
+		{QueryPolicyOverride, "https://example.com/p?x=1&utm_source=mail&ref=a%26b#top"},
+	}
+	for _, tc := range cases {
+		if got := ApplyQueryPolicy(destination, tc.policy, incoming); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.policy, tc.want, got)
+		}
+	}
+}
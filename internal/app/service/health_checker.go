@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultHealthInterval         = time.Hour
+	defaultHealthTimeout          = 10 * time.Second
+	defaultHealthConcurrency      = 8
+	defaultHealthHostInterval     = time.Second
+	defaultHealthFailureThreshold = 3
+	defaultHealthUserAgent        = "PowerURL-HealthCheck/1.0"
+
+	healthCheckBatch    = 500
+	maxHealthRedirects  = 5
+	maxHealthErrorLen   = 255
+	maxHealthBodyToRead = 64 * 1024
+)
+
+var errPrivateAddress = errors.New("destination resolves to a private or reserved network address")
+
+// HTTPDoer sends HTTP requests. *http.Client implements it.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// LinkBrokenPublisher is told when a link's destination starts failing probes.
+type LinkBrokenPublisher interface {
+	PublishLinkBroken(ctx context.Context, event model.LinkBrokenEvent) error
+}
+
+// HealthCheckerDeps groups the collaborators and settings of the health checker.
+type HealthCheckerDeps struct {
+	Logger *zap.Logger
+	Repo   repository.LinkRepository
+	// Client sends the probes. It defaults to a client that refuses to connect to
+	// private and reserved addresses unless AllowPrivateNetworks is set.
+	Client               HTTPDoer
+	AllowPrivateNetworks bool
+	// Events is told about links that became broken; optional.
+	Events           LinkBrokenPublisher
+	Interval         time.Duration
+	Timeout          time.Duration
+	Concurrency      int
+	HostInterval     time.Duration // minimum gap between probes of the same host
+	FailureThreshold int           // consecutive failures before a link counts as broken
+	UserAgent        string
+}
+
+// HealthChecker periodically probes the destination of every active link and
+// records its status, latency and failure streak on the link.
+type HealthChecker struct {
+	logger           *zap.Logger
+	repo             repository.LinkRepository
+	client           HTTPDoer
+	events           LinkBrokenPublisher
+	interval         time.Duration
+	timeout          time.Duration
+	concurrency      int
+	hostInterval     time.Duration
+	failureThreshold int
+	userAgent        string
+	stopChan         chan struct{}
+	done             chan struct{}
+}
+
+// NewHealthChecker creates a health checker, filling in defaults for unset settings.
+func NewHealthChecker(deps HealthCheckerDeps) *HealthChecker {
+	h := &HealthChecker{
+		logger:           deps.Logger,
+		repo:             deps.Repo,
+		client:           deps.Client,
+		events:           deps.Events,
+		interval:         deps.Interval,
+		timeout:          deps.Timeout,
+		concurrency:      deps.Concurrency,
+		hostInterval:     deps.HostInterval,
+		failureThreshold: deps.FailureThreshold,
+		userAgent:        deps.UserAgent,
+		stopChan:         make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+	if h.logger == nil {
+		h.logger = zap.NewNop()
+	}
+	if h.interval <= 0 {
+		h.interval = defaultHealthInterval
+	}
+	if h.timeout <= 0 {
+		h.timeout = defaultHealthTimeout
+	}
+	if h.concurrency <= 0 {
+		h.concurrency = defaultHealthConcurrency
+	}
+	if h.hostInterval <= 0 {
+		h.hostInterval = defaultHealthHostInterval
+	}
+	if h.failureThreshold <= 0 {
+		h.failureThreshold = defaultHealthFailureThreshold
+	}
+	if h.userAgent == "" {
+		h.userAgent = defaultHealthUserAgent
+	}
+	if h.client == nil {
+		h.client = newProbeClient(h.timeout, deps.AllowPrivateNetworks)
+	}
+	return h
+}
+
+// Start begins the periodic probing.
+func (h *HealthChecker) Start() {
+	go h.run()
+}
+
+// Stop stops the checker, abandoning a round of probes in progress.
+func (h *HealthChecker) Stop() {
+	close(h.stopChan)
+	<-h.done
+}
+
+func (h *HealthChecker) run() {
+	defer close(h.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-h.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.CheckAll(ctx); err != nil && ctx.Err() == nil {
+				h.logger.Error("link health check failed", zap.Error(err))
+			}
+		case <-h.stopChan:
+			h.logger.Info("link health checker stopped")
+			return
+		}
+	}
+}
+
+// CheckAll probes every active link once.
+func (h *HealthChecker) CheckAll(ctx context.Context) error {
+	gate := &hostGate{interval: h.hostInterval, next: make(map[string]time.Time)}
+	jobs := make(chan model.Link)
+
+	var wg sync.WaitGroup
+	for i := 0; i < h.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				h.checkLink(ctx, gate, &link)
+			}
+		}()
+	}
+
+	err := h.queueActiveLinks(ctx, jobs)
+	close(jobs)
+	wg.Wait()
+	return err
+}
+
+func (h *HealthChecker) queueActiveLinks(ctx context.Context, jobs chan<- model.Link) error {
+	after := ""
+	for {
+		links, err := h.repo.ListAfter(ctx, after, healthCheckBatch)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, link := range links {
+			if !healthCheckable(&link, now) {
+				continue
+			}
+			select {
+			case jobs <- link:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if len(links) < healthCheckBatch {
+			return nil
+		}
+		after = links[len(links)-1].Code
+	}
+}
+
+// healthCheckable reports whether visitors can currently reach the link's destination.
+func healthCheckable(link *model.Link, now time.Time) bool {
+	if link.Disabled || link.FlagAction == FlagActionDisable || link.NotYetActive(now) {
+		return false
+	}
+	return link.ExpiresAt == nil || now.Before(*link.ExpiresAt)
+}
+
+func (h *HealthChecker) checkLink(ctx context.Context, gate *hostGate, link *model.Link) {
+	target := ExpandDestination(link.URL, TemplateVars{Code: link.Code})
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return
+	}
+	if err := gate.wait(ctx, u.Hostname()); err != nil {
+		return
+	}
+
+	start := time.Now()
+	status, probeErr := h.probe(ctx, http.MethodHead, target)
+	if probeErr == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, probeErr = h.probe(ctx, http.MethodGet, target)
+	}
+	if ctx.Err() != nil {
+		// Interrupted by Stop; the probe says nothing about the destination.
+		return
+	}
+
+	now := time.Now()
+	health := link.Health
+	health.Status = status
+	health.LatencyMs = int(now.Sub(start).Milliseconds())
+	health.CheckedAt = &now
+	health.Error = ""
+	switch {
+	case probeErr != nil:
+		health.Failures++
+		health.Error = truncate(probeErr.Error(), maxHealthErrorLen)
+	case status == http.StatusTooManyRequests:
+		// Rate limited: inconclusive, the streak stays as it is.
+	case status >= http.StatusBadRequest:
+		health.Failures++
+		health.Error = fmt.Sprintf("HTTP %d %s", status, http.StatusText(status))
+	default:
+		health.Failures = 0
+	}
+	health.Broken = health.Failures >= h.failureThreshold
+
+	if err := h.repo.UpdateHealth(ctx, link.Code, link.URL, health); err != nil {
+		if !errors.Is(err, repository.ErrLinkNotFound) {
+			h.logger.Error("failed to store link health", zap.Error(err), zap.String("code", link.Code))
+		}
+		return
+	}
+
+	switch {
+	case health.Broken && !link.Health.Broken:
+		h.logger.Warn("link destination is broken",
+			zap.String("code", link.Code),
+			zap.String("url", link.URL),
+			zap.Int("status", health.Status),
+			zap.String("error", health.Error),
+			zap.Int("failures", health.Failures))
+		if h.events != nil {
+			event := model.LinkBrokenEvent{
+				Code:        link.Code,
+				WorkspaceID: link.WorkspaceID,
+				URL:         link.URL,
+				Status:      health.Status,
+				Error:       health.Error,
+				Failures:    health.Failures,
+				Timestamp:   now,
+			}
+			if err := h.events.PublishLinkBroken(ctx, event); err != nil {
+				h.logger.Error("failed to publish link broken event", zap.Error(err), zap.String("code", link.Code))
+			}
+		}
+	case !health.Broken && link.Health.Broken:
+		h.logger.Info("link destination recovered", zap.String("code", link.Code), zap.Int("status", health.Status))
+	}
+}
+
+// probe sends one request and returns the response status.
+func (h *HealthChecker) probe(ctx context.Context, method, target string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", h.userAgent)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHealthBodyToRead))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// newProbeClient returns a client for probing destinations. Unless allowPrivate is
+// set it checks every address it dials, so neither destinations nor their redirects
+// can reach internal services.
+func newProbeClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !publicAddr(addr) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxHealthRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxHealthRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// hostGate spaces out probes of the same host.
+type hostGate struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+// wait blocks until host may be probed again, or ctx is done.
+func (g *hostGate) wait(ctx context.Context, host string) error {
+	g.mu.Lock()
+	now := time.Now()
+	at := g.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	g.next[host] = at.Add(g.interval)
+	g.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
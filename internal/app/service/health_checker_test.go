@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+type recordingBrokenPublisher struct {
+	mu     sync.Mutex
+	events []model.LinkBrokenEvent
+}
+
+func (p *recordingBrokenPublisher) PublishLinkBroken(ctx context.Context, event model.LinkBrokenEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestHealthChecker_CheckAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	past := time.Now().Add(-time.Hour)
+	links := []model.Link{
+		{Code: "ok", URL: srv.URL + "/ok", Health: model.LinkHealth{Failures: 1}},
+		{Code: "nohead", URL: srv.URL + "/no-head"},
+		{Code: "gone", URL: srv.URL + "/gone", Health: model.LinkHealth{Failures: 1}},
+		{Code: "stillgone", URL: srv.URL + "/gone", Health: model.LinkHealth{Failures: 4, Broken: true}},
+		{Code: "busy", URL: srv.URL + "/busy", Health: model.LinkHealth{Failures: 1}},
+		{Code: "off", URL: srv.URL + "/gone", Disabled: true},
+		{Code: "expired", URL: srv.URL + "/gone", ExpiresAt: &past},
+	}
+
+	var mu sync.Mutex
+	stored := map[string]model.LinkHealth{}
+	repo := &mockLinkRepository{
+		afterFn: func(ctx context.Context, afterCode string, limit int) ([]model.Link, error) {
+			if afterCode != "" {
+				return nil, nil
+			}
+			return links, nil
+		},
+		healthFn: func(ctx context.Context, code, url string, health model.LinkHealth) error {
+			mu.Lock()
+			defer mu.Unlock()
+			stored[code] = health
+			return nil
+		},
+	}
+	events := &recordingBrokenPublisher{}
+
+	checker := NewHealthChecker(HealthCheckerDeps{
+		Repo:             repo,
+		Client:           srv.Client(),
+		Events:           events,
+		Concurrency:      3,
+		HostInterval:     time.Millisecond,
+		FailureThreshold: 2,
+	})
+	if err := checker.CheckAll(context.Background()); err != nil {
+		t.Fatalf("CheckAll error: %v", err)
+	}
+
+	if len(stored) != 5 {
+		t.Fatalf("expected 5 probed links, got %v", stored)
+	}
+	if h := stored["ok"]; h.Status != http.StatusOK || h.Failures != 0 || h.Broken || h.CheckedAt == nil {
+		t.Fatalf("unexpected health for ok: %+v", h)
+	}
+	if h := stored["nohead"]; h.Status != http.StatusOK || h.Failures != 0 {
+		t.Fatalf("expected a GET fallback for nohead, got %+v", h)
+	}
+	if h := stored["gone"]; h.Status != http.StatusNotFound || h.Failures != 2 || !h.Broken || h.Error == "" {
+		t.Fatalf("unexpected health for gone: %+v", h)
+	}
+	if h := stored["busy"]; h.Failures != 1 || h.Broken {
+		t.Fatalf("expected rate limiting to keep the streak, got %+v", h)
+	}
+	if len(events.events) != 1 || events.events[0].Code != "gone" || events.events[0].Status != http.StatusNotFound {
+		t.Fatalf("expected one broken event for gone, got %+v", events.events)
+	}
+}
+
+func TestNewProbeClient_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	resp, err := newProbeClient(time.Second, false).Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the loopback test server to be refused")
+	}
+
+	resp, err = newProbeClient(time.Second, true).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected private networks to be allowed, got %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+// LinkEventPublisher publishes link lifecycle events on plain NATS subjects.
+// Subscribers that must not miss events can capture the subjects in a stream.
+type LinkEventPublisher struct {
+	nc *nats.Conn
+}
+
+// NewLinkEventPublisher creates a publisher on the given connection.
+func NewLinkEventPublisher(nc *nats.Conn) *LinkEventPublisher {
+	return &LinkEventPublisher{nc: nc}
+}
+
+// PublishLinkBroken publishes event on model.LinkBrokenSubject.
+func (p *LinkEventPublisher) PublishLinkBroken(ctx context.Context, event model.LinkBrokenEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.nc.Publish(model.LinkBrokenSubject, data)
+}
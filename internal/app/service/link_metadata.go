@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+const (
+	defaultMetadataTimeout   = 5 * time.Second
+	defaultMetadataMaxBytes  = 512 * 1024
+	defaultMetadataUserAgent = "PowerURL-Preview/1.0"
+
+	maxPreviewTitle       = 300
+	maxPreviewDescription = 1000
+	maxPreviewURL         = 2048
+)
+
+var (
+	headEndPattern  = regexp.MustCompile(`(?i)</head\s*>`)
+	titlePattern    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title\s*>`)
+	headTagPattern  = regexp.MustCompile(`(?is)<(meta|link)\b([^>]*)>`)
+	attributeRegexp = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+))`)
+)
+
+// MetadataFetcher loads destination pages and extracts their previews. Only the
+// start of a page is read, and each fetch is bounded by a timeout.
+type MetadataFetcher struct {
+	client    HTTPDoer
+	timeout   time.Duration
+	maxBytes  int64
+	userAgent string
+}
+
+// NewMetadataFetcher builds a fetcher from configuration. Without client, pages are
+// fetched with a client that refuses private network addresses unless allowPrivate is set.
+func NewMetadataFetcher(cfg config.MetadataConfig, client HTTPDoer, allowPrivate bool) (*MetadataFetcher, error) {
+	f := &MetadataFetcher{
+		client:    client,
+		timeout:   defaultMetadataTimeout,
+		maxBytes:  defaultMetadataMaxBytes,
+		userAgent: cfg.UserAgent,
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("metadata: invalid timeout %q", cfg.Timeout)
+		}
+		f.timeout = timeout
+	}
+	if cfg.MaxBytes > 0 {
+		f.maxBytes = cfg.MaxBytes
+	}
+	if f.userAgent == "" {
+		f.userAgent = defaultMetadataUserAgent
+	}
+	if f.client == nil {
+		f.client = newProbeClient(f.timeout, allowPrivate)
+	}
+	return f, nil
+}
+
+// Fetch downloads rawURL and returns its preview. Pages that are not HTML yield a
+// preview with just a favicon.
+func (f *MetadataFetcher) Fetch(ctx context.Context, rawURL string) (model.LinkPreview, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return model.LinkPreview{}, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return model.LinkPreview{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return model.LinkPreview{}, fmt.Errorf("fetch %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+
+	// Relative references resolve against the page we ended up on after redirects.
+	base := resp.Request.URL
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return model.LinkPreview{FaviconURL: defaultFavicon(base)}, nil
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes))
+	if err != nil {
+		return model.LinkPreview{}, fmt.Errorf("read %s: %w", rawURL, err)
+	}
+	return parsePreview(page, base), nil
+}
+
+// parsePreview extracts the preview from the head of an HTML page. OpenGraph tags
+// win over their plain HTML counterparts.
+func parsePreview(page []byte, base *url.URL) model.LinkPreview {
+	head := string(page)
+	if loc := headEndPattern.FindStringIndex(head); loc != nil {
+		head = head[:loc[0]]
+	}
+	head = strings.ToValidUTF8(head, "")
+
+	meta := make(map[string]string)
+	var icon string
+	for _, match := range headTagPattern.FindAllStringSubmatch(head, -1) {
+		attrs := parseAttributes(match[2])
+		if strings.EqualFold(match[1], "meta") {
+			key := strings.ToLower(attrs["property"])
+			if key == "" {
+				key = strings.ToLower(attrs["name"])
+			}
+			if _, seen := meta[key]; key != "" && !seen {
+				meta[key] = attrs["content"]
+			}
+			continue
+		}
+		// rel="icon" and the legacy rel="shortcut icon"; apple-touch-icon is too large.
+		for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+			if rel == "icon" && icon == "" {
+				icon = attrs["href"]
+			}
+		}
+	}
+
+	preview := model.LinkPreview{
+		Title:       firstNonEmpty(meta["og:title"], meta["twitter:title"]),
+		Description: firstNonEmpty(meta["og:description"], meta["description"], meta["twitter:description"]),
+		ImageURL:    resolvePreviewURL(base, firstNonEmpty(meta["og:image"], meta["og:image:url"], meta["twitter:image"])),
+		FaviconURL:  resolvePreviewURL(base, icon),
+	}
+	if preview.Title == "" {
+		if match := titlePattern.FindStringSubmatch(head); match != nil {
+			preview.Title = match[1]
+		}
+	}
+	preview.Title = cleanPreviewText(preview.Title, maxPreviewTitle)
+	preview.Description = cleanPreviewText(preview.Description, maxPreviewDescription)
+	if preview.FaviconURL == "" {
+		preview.FaviconURL = defaultFavicon(base)
+	}
+	return preview
+}
+
+// parseAttributes returns the lower-cased attribute names of a tag with their unescaped values.
+func parseAttributes(raw string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range attributeRegexp.FindAllStringSubmatch(raw, -1) {
+		name := strings.ToLower(match[1])
+		if _, seen := attrs[name]; !seen {
+			attrs[name] = html.UnescapeString(match[2] + match[3] + match[4])
+		}
+	}
+	return attrs
+}
+
+// cleanPreviewText unescapes s, collapses its whitespace and cuts it to n bytes.
+func cleanPreviewText(s string, n int) string {
+	s = strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+	if len(s) > n {
+		s = strings.TrimSpace(truncate(s, n-len("…"))) + "…"
+	}
+	return s
+}
+
+// resolvePreviewURL resolves ref against base, keeping only reasonably sized http(s) URLs.
+func resolvePreviewURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	resolved := u.String()
+	if len(resolved) > maxPreviewURL {
+		return ""
+	}
+	return resolved
+}
+
+func defaultFavicon(base *url.URL) string {
+	if base == nil {
+		return ""
+	}
+	return resolvePreviewURL(base, "/favicon.ico")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
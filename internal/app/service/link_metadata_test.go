@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+func TestParsePreview(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post")
+	page := `<!doctype html><html><head>
+		<title>  Fallback &amp; title </title>
+		<meta property="og:title" content="Launch &amp; Learn">
+		<meta name='description' content="Plain
+			description">
+		<META PROPERTY="og:image" CONTENT="/img/cover.png">
+		<link rel="shortcut icon" href="icons/fav.ico">
+	</head><body><meta property="og:title" content="from the body"></body></html>`
+
+	preview := parsePreview([]byte(page), base)
+	want := model.LinkPreview{
+		Title:       "Launch & Learn",
+		Description: "Plain description",
+		ImageURL:    "https://example.com/img/cover.png",
+		FaviconURL:  "https://example.com/blog/icons/fav.ico",
+	}
+	if preview != want {
+		t.Fatalf("unexpected preview:\n got %+v\nwant %+v", preview, want)
+	}
+
+	preview = parsePreview([]byte(`<title>Only a title</title><link rel="icon" href="javascript:alert(1)">`), base)
+	if preview.Title != "Only a title" || preview.FaviconURL != "https://example.com/favicon.ico" {
+		t.Fatalf("expected the <title> and the default favicon, got %+v", preview)
+	}
+
+	preview = parsePreview([]byte(`<title>`+strings.Repeat("é", maxPreviewTitle)+`</title>`), base)
+	if len(preview.Title) > maxPreviewTitle || !strings.HasSuffix(preview.Title, "…") {
+		t.Fatalf("expected a truncated title, got %d bytes", len(preview.Title))
+	}
+}
+
+func TestMetadataFetcher_Fetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/docs/page", http.StatusFound)
+		case "/docs/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<head><title>Docs</title><link rel="icon" href="fav.png"></head>` + strings.Repeat("x", 4096) + `<title>ignored</title>`))
+		case "/file.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewMetadataFetcher(config.MetadataConfig{MaxBytes: 1024}, srv.Client(), false)
+	if err != nil {
+		t.Fatalf("NewMetadataFetcher error: %v", err)
+	}
+
+	preview, err := fetcher.Fetch(context.Background(), srv.URL+"/moved")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if preview.Title != "Docs" || preview.FaviconURL != srv.URL+"/docs/fav.png" {
+		t.Fatalf("expected the redirect target's preview, got %+v", preview)
+	}
+
+	preview, err = fetcher.Fetch(context.Background(), srv.URL+"/file.pdf")
+	if err != nil || preview.Title != "" || preview.FaviconURL != srv.URL+"/favicon.ico" {
+		t.Fatalf("expected only a favicon for non-HTML pages, got %+v, %v", preview, err)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), srv.URL+"/missing"); err == nil {
+		t.Fatal("expected an error for a missing page")
+	}
+
+	if _, err := NewMetadataFetcher(config.MetadataConfig{Timeout: "soon"}, nil, false); err == nil {
+		t.Fatal("expected an invalid timeout to be rejected")
+	}
+}
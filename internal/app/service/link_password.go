@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ModePassword is the redirect mode that asks visitors for a password first.
+const ModePassword = "password"
+
+// maxLinkPasswordLen is the longest input bcrypt hashes without truncation.
+const maxLinkPasswordLen = 72
+
+// HashLinkPassword returns the bcrypt hash stored for a link password.
+func HashLinkPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckLinkPassword reports whether password unlocks link.
+func CheckLinkPassword(link *model.Link, password string) bool {
+	if link.PasswordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) == nil
+}
+
+// applyPassword hashes a newly supplied password onto link and enforces that exactly
+// password-mode links carry a hash. A nil or empty password keeps the current hash.
+func applyPassword(link *model.Link, password *string) error {
+	supplied := password != nil && *password != ""
+	if link.Mode != ModePassword {
+		if supplied {
+			return &ValidationError{Fields: []FieldError{{Field: "password", Message: "requires mode password"}}}
+		}
+		link.PasswordHash = ""
+		return nil
+	}
+
+	if supplied {
+		if len(*password) > maxLinkPasswordLen {
+			return &ValidationError{Fields: []FieldError{{Field: "password", Message: fmt.Sprintf("must be at most %d bytes", maxLinkPasswordLen)}}}
+		}
+		hash, err := HashLinkPassword(*password)
+		if err != nil {
+			return fmt.Errorf("hash password: %w", err)
+		}
+		link.PasswordHash = hash
+	}
+	if link.PasswordHash == "" {
+		return &ValidationError{Fields: []FieldError{{Field: "password", Message: "is required for mode password"}}}
+	}
+	return nil
+}
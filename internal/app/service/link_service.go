@@ -2,28 +2,123 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/sifan077/PowerURL/config"
 	"github.com/sifan077/PowerURL/internal/app/model"
 	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
 )
 
 // LinkService defines behaviour-level operations on links.
 type LinkService interface {
 	CreateLink(ctx context.Context, input CreateLinkInput) (*model.Link, error)
+	BulkCreateLinks(ctx context.Context, inputs []CreateLinkInput, allOrNothing bool) ([]BulkCreateResult, error)
 	GetLink(ctx context.Context, code string) (*model.Link, error)
 	ListLinks(ctx context.Context, limit, offset int) ([]model.Link, error)
+	QueryLinks(ctx context.Context, query repository.LinkQuery) (*repository.LinkPage, error)
 	UpdateLink(ctx context.Context, code string, input UpdateLinkInput) (*model.Link, error)
+	DeleteLink(ctx context.Context, code string) error
+	RestoreLink(ctx context.Context, code string) (*model.Link, error)
+	ListDeletedLinks(ctx context.Context, limit, offset int) ([]model.Link, error)
+	PurgeLink(ctx context.Context, code string) error
+	ListRevisions(ctx context.Context, code string, limit, offset int) ([]model.LinkRevision, error)
+	RevertLink(ctx context.Context, code string, revisionID uint, ifVersions []int) (*model.Link, error)
+	ResetClickCount(ctx context.Context, code string) (*model.Link, error)
+	ReplaceRules(ctx context.Context, code string, rules model.LinkRules, ifVersions []int) (*model.Link, error)
+	AddRule(ctx context.Context, code string, rule model.LinkRule, position int, ifVersions []int) (*model.Link, error)
+	UpdateRule(ctx context.Context, code, ruleID string, rule model.LinkRule, ifVersions []int) (*model.Link, error)
+	DeleteRule(ctx context.Context, code, ruleID string, ifVersions []int) (*model.Link, error)
+	ListFlaggedLinks(ctx context.Context, limit, offset int) ([]model.Link, error)
+	ClearFlag(ctx context.Context, code string) (*model.Link, error)
+}
+
+var (
+	// ErrCodeGenerationExhausted is returned when every generated code collided with an existing link.
+	ErrCodeGenerationExhausted = errors.New("could not generate a unique link code")
+	// ErrBulkAborted marks rows that were not written because an all-or-nothing bulk create failed.
+	ErrBulkAborted = errors.New("bulk create aborted")
+	// ErrRuleNotFound is returned when a link has no routing rule with the requested ID.
+	ErrRuleNotFound = errors.New("routing rule not found")
+)
+
+// maxUpdateAttempts bounds retries of an unconditional update that races with another writer.
+const maxUpdateAttempts = 3
+
+// LinkServiceDeps groups the collaborators of the link service.
+type LinkServiceDeps struct {
+	Logger          *zap.Logger
+	Repo            repository.LinkRepository
+	CodeGenerator   CodeGenerator
+	CodePolicy      *CodePolicy
+	URLPolicy       *URLPolicy
+	ThreatFeed      ThreatFeed // optional; rejects destinations on a blocklist
+	MaxCodeAttempts int
+	// QuarantinePeriod keeps purged codes unusable for this long.
+	QuarantinePeriod time.Duration
+	// ClickCounter supplies live click counts; without it the stored count is reported.
+	ClickCounter repository.ClickCounter
+	// Metadata is asked to fetch the preview of new destinations; optional.
+	Metadata MetadataRequester
 }
 
 type linkService struct {
-	repo repository.LinkRepository
+	logger           *zap.Logger
+	repo             repository.LinkRepository
+	codeGenerator    CodeGenerator
+	codePolicy       *CodePolicy
+	urlPolicy        *URLPolicy
+	threatFeed       ThreatFeed
+	maxCodeAttempts  int
+	quarantinePeriod time.Duration
+	clickCounter     repository.ClickCounter
+	metadata         MetadataRequester
 }
 
 // NewLinkService returns a service implementation backed by the given repository.
 func NewLinkService(repo repository.LinkRepository) LinkService {
-	return &linkService{repo: repo}
+	return NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo})
+}
+
+// NewLinkServiceWithDeps returns a service implementation wired with the provided dependencies.
+func NewLinkServiceWithDeps(deps LinkServiceDeps) LinkService {
+	generator := deps.CodeGenerator
+	if generator == nil {
+		generator = NewRandomCodeGenerator(defaultCodeLength)
+	}
+	policy := deps.CodePolicy
+	if policy == nil {
+		// The zero config always yields a valid policy.
+		policy, _ = NewCodePolicy(config.CodePolicyConfig{})
+	}
+	urlPolicy := deps.URLPolicy
+	if urlPolicy == nil {
+		urlPolicy, _ = NewURLPolicy(config.URLPolicyConfig{}, nil)
+	}
+	attempts := deps.MaxCodeAttempts
+	if attempts <= 0 {
+		attempts = defaultCodeMaxAttempts
+	}
+	logger := deps.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &linkService{
+		logger:           logger,
+		repo:             deps.Repo,
+		codeGenerator:    generator,
+		codePolicy:       policy,
+		urlPolicy:        urlPolicy,
+		threatFeed:       deps.ThreatFeed,
+		maxCodeAttempts:  attempts,
+		quarantinePeriod: deps.QuarantinePeriod,
+		clickCounter:     deps.ClickCounter,
+		metadata:         deps.Metadata,
+	}
 }
 
 // CreateLinkInput captures data required to create a link.
@@ -33,7 +128,16 @@ type CreateLinkInput struct {
 	Mode         string
 	TimerSeconds int
 	Disabled     bool
+	StartsAt     *time.Time
 	ExpiresAt    *time.Time
+	MaxClicks    *int   // nil or 0 leaves the link uncapped
+	Password     string // required for ModePassword; stored as a bcrypt hash
+	Rules        model.LinkRules
+	GeoTargets   model.GeoTargets
+	Variants     model.LinkVariants
+	QueryPolicy  string // QueryPolicyIgnore when empty
+	ForwardPath  bool
+	Social       model.LinkSocial
 }
 
 // UpdateLinkInput captures fields that can be changed on an existing link.
@@ -42,38 +146,413 @@ type UpdateLinkInput struct {
 	Mode         *string
 	TimerSeconds *int
 	Disabled     *bool
+	StartsAt     *time.Time
 	ExpiresAt    *time.Time
+	MaxClicks    *int // 0 removes the cap
+	QueryPolicy  *string
+	ForwardPath  *bool
+	// Social replaces the overrides shown to social crawlers; empty fields remove them.
+	Social *model.LinkSocial
+	// Password replaces the password of a ModePassword link; nil or empty keeps it.
+	Password *string
+	// GeoTargets replaces the link's geo targets; an empty map removes them.
+	GeoTargets *model.GeoTargets
+	// Variants replaces the link's A/B variants; an empty list ends the test.
+	Variants *model.LinkVariants
+	// IfVersions makes the update fail with repository.ErrVersionConflict unless
+	// the link is still at one of these versions. Nil skips the check.
+	IfVersions []int
 }
 
-func (s *linkService) CreateLink(ctx context.Context, input CreateLinkInput) (*model.Link, error) {
+// BulkCreateResult reports the outcome of a single row of a bulk create.
+type BulkCreateResult struct {
+	Link *model.Link
+	Err  error
+}
+
+func newLinkFromInput(input CreateLinkInput) *model.Link {
 	link := &model.Link{
 		Code:         input.Code,
 		URL:          input.URL,
 		Mode:         input.Mode,
 		TimerSeconds: input.TimerSeconds,
 		Disabled:     input.Disabled,
+		StartsAt:     input.StartsAt,
 		ExpiresAt:    input.ExpiresAt,
+		Rules:        input.Rules,
+		GeoTargets:   input.GeoTargets,
+		Variants:     input.Variants,
+		QueryPolicy:  input.QueryPolicy,
+		ForwardPath:  input.ForwardPath,
+		Social:       input.Social,
+		Version:      1,
+	}
+	if input.MaxClicks != nil && *input.MaxClicks > 0 {
+		link.MaxClicks = input.MaxClicks
 	}
 
 	if link.Mode == "" {
 		link.Mode = "direct"
 	}
+	if link.QueryPolicy == "" {
+		link.QueryPolicy = QueryPolicyIgnore
+	}
+	return link
+}
+
+// assignOwner stamps link with the calling actor's workspace and identity.
+func assignOwner(ctx context.Context, link *model.Link) {
+	link.WorkspaceID = model.DefaultWorkspaceID
+	if actor, ok := ActorFromContext(ctx); ok {
+		link.WorkspaceID = actor.WorkspaceID
+		link.OwnerID = actor.OwnerID
+	}
+}
+
+// authorize hides links outside the calling actor's workspace behind ErrLinkNotFound.
+func authorize(ctx context.Context, link *model.Link) error {
+	if actor, ok := ActorFromContext(ctx); ok && !link.InWorkspace(actor.WorkspaceID) {
+		return repository.ErrLinkNotFound
+	}
+	return nil
+}
 
-	if err := s.repo.Create(ctx, link); err != nil {
-		return nil, fmt.Errorf("create link: %w", err)
+// ownedLink loads a live link the calling actor may access.
+func (s *linkService) ownedLink(ctx context.Context, code string) (*model.Link, error) {
+	link, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, link); err != nil {
+		return nil, err
 	}
 	return link, nil
 }
 
+// checkOwnedIncludingDeleted verifies the actor may access code, even when the link is in the trash.
+func (s *linkService) checkOwnedIncludingDeleted(ctx context.Context, code string) error {
+	if _, ok := ActorFromContext(ctx); !ok {
+		return nil
+	}
+	link, err := s.repo.GetIncludingDeleted(ctx, code)
+	if err != nil {
+		return err
+	}
+	return authorize(ctx, link)
+}
+
+func (s *linkService) CreateLink(ctx context.Context, input CreateLinkInput) (*model.Link, error) {
+	link := newLinkFromInput(input)
+	assignOwner(ctx, link)
+	if fieldErr := validateSchedule(link); fieldErr != nil {
+		return nil, &ValidationError{Fields: []FieldError{*fieldErr}}
+	}
+	if fieldErr := validateDestinationOptions(link); fieldErr != nil {
+		return nil, &ValidationError{Fields: []FieldError{*fieldErr}}
+	}
+	if fieldErr := prepareSocial(&link.Social); fieldErr != nil {
+		return nil, &ValidationError{Fields: []FieldError{*fieldErr}}
+	}
+	if err := applyPassword(link, &input.Password); err != nil {
+		return nil, err
+	}
+	if err := prepareRules(link.Rules); err != nil {
+		return nil, err
+	}
+	targets, err := prepareGeoTargets(link.GeoTargets)
+	if err != nil {
+		return nil, err
+	}
+	link.GeoTargets = targets
+	if err := validateVariants(link.Variants); err != nil {
+		return nil, err
+	}
+	if err := s.checkURLPolicy(ctx, linkDestinations(link)); err != nil {
+		return nil, err
+	}
+
+	if link.Code != "" {
+		if fieldErr := s.codePolicy.Validate(link.Code); fieldErr != nil {
+			return nil, &ValidationError{Fields: []FieldError{*fieldErr}}
+		}
+		if err := s.repo.Create(ctx, link); err != nil {
+			return nil, fmt.Errorf("create link: %w", err)
+		}
+		s.requestMetadata(link)
+		return link, nil
+	}
+
+	for attempt := 0; attempt < s.maxCodeAttempts; attempt++ {
+		code, err := s.codeGenerator.Generate(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("generate code: %w", err)
+		}
+		if s.codePolicy.Validate(code) != nil {
+			continue
+		}
+		link.Code = code
+
+		err = s.repo.Create(ctx, link)
+		if err == nil {
+			s.requestMetadata(link)
+			return link, nil
+		}
+		if !isCodeConflict(err) {
+			return nil, fmt.Errorf("create link: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("create link: %w", ErrCodeGenerationExhausted)
+}
+
+// BulkCreateLinks creates many links using batched writes. Results are aligned with
+// inputs. With allOrNothing every row is written in one transaction, and a failure on
+// any row leaves the others marked with ErrBulkAborted.
+func (s *linkService) BulkCreateLinks(ctx context.Context, inputs []CreateLinkInput, allOrNothing bool) ([]BulkCreateResult, error) {
+	results := make([]BulkCreateResult, len(inputs))
+	links := make([]*model.Link, len(inputs))
+	generated := make([]bool, len(inputs))
+	pending := make([]int, 0, len(inputs))
+
+	for i, input := range inputs {
+		link := newLinkFromInput(input)
+		assignOwner(ctx, link)
+		if fieldErr := validateSchedule(link); fieldErr != nil {
+			results[i].Err = &ValidationError{Fields: []FieldError{*fieldErr}}
+			continue
+		}
+		if fieldErr := validateDestinationOptions(link); fieldErr != nil {
+			results[i].Err = &ValidationError{Fields: []FieldError{*fieldErr}}
+			continue
+		}
+		if fieldErr := prepareSocial(&link.Social); fieldErr != nil {
+			results[i].Err = &ValidationError{Fields: []FieldError{*fieldErr}}
+			continue
+		}
+		if err := applyPassword(link, &input.Password); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := prepareRules(link.Rules); err != nil {
+			results[i].Err = err
+			continue
+		}
+		targets, err := prepareGeoTargets(link.GeoTargets)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		link.GeoTargets = targets
+		if err := validateVariants(link.Variants); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := s.checkURLPolicy(ctx, linkDestinations(link)); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if link.Code == "" {
+			code, err := s.generateCode(ctx)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			link.Code = code
+			generated[i] = true
+		} else if fieldErr := s.codePolicy.Validate(link.Code); fieldErr != nil {
+			results[i].Err = &ValidationError{Fields: []FieldError{*fieldErr}}
+			continue
+		}
+		links[i] = link
+		pending = append(pending, i)
+	}
+
+	if allOrNothing && len(pending) != len(inputs) {
+		return abortBulk(results), ErrBulkAborted
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		batch := make([]*model.Link, len(pending))
+		for j, idx := range pending {
+			batch[j] = links[idx]
+		}
+
+		rowErrs, err := s.repo.CreateBatch(ctx, batch, allOrNothing)
+		if err != nil && !errors.Is(err, repository.ErrBatchAborted) {
+			return nil, fmt.Errorf("bulk create links: %w", err)
+		}
+		// An aborted batch without a failing row rolled back for a reason no row can
+		// be blamed for; none of the links was written.
+		if err != nil && !slices.ContainsFunc(rowErrs, func(rowErr error) bool { return rowErr != nil }) {
+			for _, idx := range pending {
+				results[idx].Err = err
+			}
+			return results, ErrBulkAborted
+		}
+
+		var retry []int
+		for j, idx := range pending {
+			rowErr := rowErrs[j]
+			if rowErr == nil {
+				continue
+			}
+			if generated[idx] && isCodeConflict(rowErr) && attempt < s.maxCodeAttempts {
+				code, genErr := s.generateCode(ctx)
+				if genErr == nil {
+					links[idx].Code = code
+					retry = append(retry, idx)
+					continue
+				}
+				rowErr = genErr
+			}
+			results[idx].Err = rowErr
+		}
+
+		if allOrNothing {
+			for _, idx := range pending {
+				if results[idx].Err != nil {
+					return abortBulk(results), ErrBulkAborted
+				}
+			}
+			if len(retry) == 0 {
+				break
+			}
+			// Nothing was written; replay the whole batch with the regenerated codes.
+			continue
+		}
+		pending = retry
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Link = links[i]
+			s.requestMetadata(links[i])
+		}
+	}
+	return results, nil
+}
+
+// generateCode returns a policy-compliant candidate code. Uniqueness is checked on insert.
+func (s *linkService) generateCode(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < s.maxCodeAttempts; attempt++ {
+		code, err := s.codeGenerator.Generate(ctx)
+		if err != nil {
+			return "", fmt.Errorf("generate code: %w", err)
+		}
+		if s.codePolicy.Validate(code) == nil {
+			return code, nil
+		}
+	}
+	return "", ErrCodeGenerationExhausted
+}
+
+// validateSchedule rejects activation windows that never open.
+func validateSchedule(link *model.Link) *FieldError {
+	if link.StartsAt != nil && link.ExpiresAt != nil && !link.StartsAt.Before(*link.ExpiresAt) {
+		return &FieldError{Field: "starts_at", Message: "must be before expires_at"}
+	}
+	return nil
+}
+
+// destinationField is a destination URL and the request field it came from.
+type destinationField struct {
+	field string
+	url   string
+}
+
+// checkURLPolicy validates destinations against the URL policy and the threat feed,
+// returning a *ValidationError.
+func (s *linkService) checkURLPolicy(ctx context.Context, destinations []destinationField) error {
+	var fields []FieldError
+	for _, d := range destinations {
+		if fieldErr := s.urlPolicy.Validate(ctx, d.field, d.url); fieldErr != nil {
+			fields = append(fields, *fieldErr)
+			continue
+		}
+		if s.threatFeed == nil {
+			continue
+		}
+		if reason := s.threatFeed.Match(d.url); reason != "" {
+			fields = append(fields, FieldError{Field: d.field, Message: "is on a malicious-URL blocklist (" + reason + ")"})
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// linkDestinations lists every URL link may redirect to.
+func linkDestinations(link *model.Link) []destinationField {
+	destinations := []destinationField{{field: "url", url: link.URL}}
+	destinations = append(destinations, ruleDestinations(link.Rules)...)
+	destinations = append(destinations, geoTargetDestinations(link.GeoTargets)...)
+	return append(destinations, variantDestinations(link.Variants)...)
+}
+
+func ruleDestinations(rules model.LinkRules) []destinationField {
+	destinations := make([]destinationField, len(rules))
+	for i, rule := range rules {
+		destinations[i] = destinationField{field: fmt.Sprintf("rules[%d].destination", i), url: rule.Destination}
+	}
+	return destinations
+}
+
+func geoTargetDestinations(targets model.GeoTargets) []destinationField {
+	destinations := make([]destinationField, 0, len(targets))
+	for _, key := range slices.Sorted(maps.Keys(targets)) {
+		destinations = append(destinations, destinationField{field: "geo_targets." + key, url: targets[key]})
+	}
+	return destinations
+}
+
+func variantDestinations(variants model.LinkVariants) []destinationField {
+	destinations := make([]destinationField, len(variants))
+	for i, variant := range variants {
+		destinations[i] = destinationField{field: fmt.Sprintf("variants[%d].destination", i), url: variant.Destination}
+	}
+	return destinations
+}
+
+func abortBulk(results []BulkCreateResult) []BulkCreateResult {
+	for i := range results {
+		results[i].Link = nil
+		if results[i].Err == nil {
+			results[i].Err = ErrBulkAborted
+		}
+	}
+	return results
+}
+
+func isCodeConflict(err error) bool {
+	return errors.Is(err, repository.ErrDuplicateCode) || errors.Is(err, repository.ErrCodeQuarantined)
+}
+
 func (s *linkService) GetLink(ctx context.Context, code string) (*model.Link, error) {
-	link, err := s.repo.GetByCode(ctx, code)
+	link, err := s.ownedLink(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("get link: %w", err)
 	}
+	if s.clickCounter != nil {
+		count, err := s.clickCounter.Current(ctx, code)
+		if err != nil {
+			// The stored count lags behind by the clicks not yet consumed, which beats failing.
+			s.logger.Warn("failed to read live click count, reporting the stored one", zap.Error(err), zap.String("code", code))
+		} else {
+			link.ClickCount = count
+		}
+	}
 	return link, nil
 }
 
 func (s *linkService) ListLinks(ctx context.Context, limit, offset int) ([]model.Link, error) {
+	if _, ok := ActorFromContext(ctx); ok {
+		page, err := s.QueryLinks(ctx, repository.LinkQuery{Limit: limit, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		return page.Links, nil
+	}
+
 	links, err := s.repo.List(ctx, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("list links: %w", err)
@@ -81,12 +560,113 @@ func (s *linkService) ListLinks(ctx context.Context, limit, offset int) ([]model
 	return links, nil
 }
 
+func (s *linkService) QueryLinks(ctx context.Context, query repository.LinkQuery) (*repository.LinkPage, error) {
+	if actor, ok := ActorFromContext(ctx); ok {
+		query.WorkspaceID = actor.WorkspaceID
+	}
+	page, err := s.repo.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query links: %w", err)
+	}
+	return page, nil
+}
+
 func (s *linkService) UpdateLink(ctx context.Context, code string, input UpdateLinkInput) (*model.Link, error) {
-	link, err := s.repo.GetByCode(ctx, code)
+	var destinations []destinationField
+	if input.URL != nil {
+		destinations = append(destinations, destinationField{field: "url", url: *input.URL})
+	}
+	if input.GeoTargets != nil {
+		destinations = append(destinations, geoTargetDestinations(*input.GeoTargets)...)
+	}
+	if input.Variants != nil {
+		destinations = append(destinations, variantDestinations(*input.Variants)...)
+	}
+
+	var previousURL string
+	link, err := s.modify(ctx, code, input.IfVersions, func(link *model.Link) error {
+		previousURL = link.URL
+		applyUpdate(link, input)
+		if fieldErr := validateSchedule(link); fieldErr != nil {
+			return &ValidationError{Fields: []FieldError{*fieldErr}}
+		}
+		if fieldErr := validateDestinationOptions(link); fieldErr != nil {
+			return &ValidationError{Fields: []FieldError{*fieldErr}}
+		}
+		if fieldErr := prepareSocial(&link.Social); fieldErr != nil {
+			return &ValidationError{Fields: []FieldError{*fieldErr}}
+		}
+		if input.GeoTargets != nil {
+			targets, err := prepareGeoTargets(*input.GeoTargets)
+			if err != nil {
+				return err
+			}
+			link.GeoTargets = targets
+		}
+		if input.Variants != nil {
+			if err := validateVariants(*input.Variants); err != nil {
+				return err
+			}
+			link.Variants = *input.Variants
+		}
+		if err := applyPassword(link, input.Password); err != nil {
+			return err
+		}
+		return s.checkURLPolicy(ctx, destinations)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("load link: %w", err)
+		return nil, err
+	}
+	if link.URL != previousURL {
+		s.requestMetadata(link)
+	}
+	return link, nil
+}
+
+// requestMetadata queues fetching the preview of the link's URL unless it is current.
+func (s *linkService) requestMetadata(link *model.Link) {
+	if s.metadata == nil || link.Preview.Describes(link.URL) {
+		return
+	}
+	s.metadata.RequestMetadata(link.Code, link.URL)
+}
+
+// modify applies change to a freshly loaded link and saves it. The write is conditional
+// on the loaded version; without ifVersions a concurrent change is retried on top of
+// the new state.
+func (s *linkService) modify(ctx context.Context, code string, ifVersions []int, change func(*model.Link) error) (*model.Link, error) {
+	return s.modifyWith(ctx, code, ifVersions, change, s.repo.Update)
+}
+
+// modifyWith is modify with save writing the changed link instead of Update.
+func (s *linkService) modifyWith(ctx context.Context, code string, ifVersions []int, change func(*model.Link) error, save func(context.Context, *model.Link) error) (*model.Link, error) {
+	for attempt := 1; ; attempt++ {
+		link, err := s.repo.GetByCodeUncached(ctx, code)
+		if err == nil {
+			err = authorize(ctx, link)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load link: %w", err)
+		}
+		if ifVersions != nil && !slices.Contains(ifVersions, link.Version) {
+			return nil, fmt.Errorf("update link: %w", repository.ErrVersionConflict)
+		}
+
+		if err := change(link); err != nil {
+			return nil, err
+		}
+
+		err = save(ctx, link)
+		if err == nil {
+			return link, nil
+		}
+		if !errors.Is(err, repository.ErrVersionConflict) || ifVersions != nil || attempt >= maxUpdateAttempts {
+			return nil, fmt.Errorf("update link: %w", err)
+		}
 	}
+}
 
+func applyUpdate(link *model.Link, input UpdateLinkInput) {
 	if input.URL != nil {
 		link.URL = *input.URL
 	}
@@ -99,12 +679,210 @@ func (s *linkService) UpdateLink(ctx context.Context, code string, input UpdateL
 	if input.Disabled != nil {
 		link.Disabled = *input.Disabled
 	}
+	if input.StartsAt != nil {
+		link.StartsAt = input.StartsAt
+	}
 	if input.ExpiresAt != nil {
 		link.ExpiresAt = input.ExpiresAt
 	}
+	if input.MaxClicks != nil {
+		link.MaxClicks = input.MaxClicks
+		if *input.MaxClicks <= 0 {
+			link.MaxClicks = nil
+		}
+	}
+	if input.QueryPolicy != nil {
+		link.QueryPolicy = *input.QueryPolicy
+		if link.QueryPolicy == "" {
+			link.QueryPolicy = QueryPolicyIgnore
+		}
+	}
+	if input.ForwardPath != nil {
+		link.ForwardPath = *input.ForwardPath
+	}
+	if input.Social != nil {
+		link.Social = *input.Social
+	}
+}
 
-	if err := s.repo.Update(ctx, link); err != nil {
-		return nil, fmt.Errorf("update link: %w", err)
+func (s *linkService) DeleteLink(ctx context.Context, code string) error {
+	if _, err := s.ownedLink(ctx, code); err != nil {
+		return fmt.Errorf("load link: %w", err)
+	}
+	if err := s.repo.Delete(ctx, code); err != nil {
+		return fmt.Errorf("delete link: %w", err)
+	}
+	return nil
+}
+
+func (s *linkService) RestoreLink(ctx context.Context, code string) (*model.Link, error) {
+	if err := s.checkOwnedIncludingDeleted(ctx, code); err != nil {
+		return nil, fmt.Errorf("load link: %w", err)
+	}
+	link, err := s.repo.Restore(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("restore link: %w", err)
 	}
 	return link, nil
 }
+
+func (s *linkService) ListDeletedLinks(ctx context.Context, limit, offset int) ([]model.Link, error) {
+	workspaceID := ""
+	if actor, ok := ActorFromContext(ctx); ok {
+		workspaceID = actor.WorkspaceID
+	}
+	links, err := s.repo.ListDeleted(ctx, workspaceID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list deleted links: %w", err)
+	}
+	return links, nil
+}
+
+func (s *linkService) PurgeLink(ctx context.Context, code string) error {
+	if err := s.checkOwnedIncludingDeleted(ctx, code); err != nil {
+		return fmt.Errorf("load link: %w", err)
+	}
+	until := time.Now().Add(s.quarantinePeriod)
+	if err := s.repo.Purge(ctx, code, until); err != nil {
+		return fmt.Errorf("purge link: %w", err)
+	}
+	if s.clickCounter != nil {
+		// The code may be reused after quarantine; it must start from zero.
+		if err := s.clickCounter.Forget(ctx, code); err != nil {
+			return fmt.Errorf("forget click count: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *linkService) ListRevisions(ctx context.Context, code string, limit, offset int) ([]model.LinkRevision, error) {
+	if _, err := s.ownedLink(ctx, code); err != nil {
+		return nil, fmt.Errorf("load link: %w", err)
+	}
+	revisions, err := s.repo.ListRevisions(ctx, code, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// RevertLink restores the settings of revision revisionID. Its destinations must pass
+// the URL policy and threat feed as they stand today, like those of any update.
+// With ifVersions the revert only applies on top of one of those versions.
+func (s *linkService) RevertLink(ctx context.Context, code string, revisionID uint, ifVersions []int) (*model.Link, error) {
+	var revision *model.LinkRevision
+	var previousURL string
+	save := func(ctx context.Context, link *model.Link) error {
+		return s.repo.RevertToRevision(ctx, link, revisionID)
+	}
+	link, err := s.modifyWith(ctx, code, ifVersions, func(link *model.Link) error {
+		if revision == nil {
+			loaded, err := s.repo.GetRevision(ctx, code, revisionID)
+			if err != nil {
+				return fmt.Errorf("load revision: %w", err)
+			}
+			revision = loaded
+		}
+		previousURL = link.URL
+		revision.Restore(link)
+		return s.checkURLPolicy(ctx, linkDestinations(link))
+	}, save)
+	if err != nil {
+		return nil, err
+	}
+	if link.URL != previousURL {
+		s.requestMetadata(link)
+	}
+	return link, nil
+}
+
+// ResetClickCount zeroes the live click counter, re-enabling a link that reached MaxClicks.
+func (s *linkService) ResetClickCount(ctx context.Context, code string) (*model.Link, error) {
+	link, err := s.ownedLink(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("load link: %w", err)
+	}
+	if s.clickCounter != nil {
+		if err := s.clickCounter.Reset(ctx, code); err != nil {
+			return nil, fmt.Errorf("reset click count: %w", err)
+		}
+	}
+	link.ClickCount = 0
+	return link, nil
+}
+
+// ListFlaggedLinks lists links the threat scanner flagged, across all workspaces,
+// for trust & safety review.
+func (s *linkService) ListFlaggedLinks(ctx context.Context, limit, offset int) ([]model.Link, error) {
+	links, err := s.repo.ListFlagged(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list flagged links: %w", err)
+	}
+	return links, nil
+}
+
+// ClearFlag lifts a threat flag after review, in any workspace. The scanner does not
+// flag the link again until its settings change.
+func (s *linkService) ClearFlag(ctx context.Context, code string) (*model.Link, error) {
+	link, err := s.repo.ClearFlag(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("clear flag: %w", err)
+	}
+	return link, nil
+}
+
+// ReplaceRules swaps the link's routing rules for rules, e.g. to reorder them.
+func (s *linkService) ReplaceRules(ctx context.Context, code string, rules model.LinkRules, ifVersions []int) (*model.Link, error) {
+	return s.modify(ctx, code, ifVersions, func(link *model.Link) error {
+		link.Rules = slices.Clone(rules)
+		if err := prepareRules(link.Rules); err != nil {
+			return err
+		}
+		return s.checkURLPolicy(ctx, ruleDestinations(link.Rules))
+	})
+}
+
+// AddRule inserts rule at position, appending when position is negative or past the end.
+func (s *linkService) AddRule(ctx context.Context, code string, rule model.LinkRule, position int, ifVersions []int) (*model.Link, error) {
+	rule.ID = ""
+	return s.modify(ctx, code, ifVersions, func(link *model.Link) error {
+		if position < 0 || position > len(link.Rules) {
+			position = len(link.Rules)
+		}
+		link.Rules = slices.Insert(slices.Clone(link.Rules), position, rule)
+		if err := prepareRules(link.Rules); err != nil {
+			return err
+		}
+		return s.checkURLPolicy(ctx, []destinationField{{field: "destination", url: rule.Destination}})
+	})
+}
+
+// UpdateRule replaces the conditions and destination of the rule with ruleID in place.
+func (s *linkService) UpdateRule(ctx context.Context, code, ruleID string, rule model.LinkRule, ifVersions []int) (*model.Link, error) {
+	rule.ID = ruleID
+	return s.modify(ctx, code, ifVersions, func(link *model.Link) error {
+		link.Rules = slices.Clone(link.Rules)
+		existing := link.Rules.Rule(ruleID)
+		if existing == nil {
+			return ErrRuleNotFound
+		}
+		*existing = rule
+		if err := prepareRules(link.Rules); err != nil {
+			return err
+		}
+		return s.checkURLPolicy(ctx, []destinationField{{field: "destination", url: rule.Destination}})
+	})
+}
+
+func (s *linkService) DeleteRule(ctx context.Context, code, ruleID string, ifVersions []int) (*model.Link, error) {
+	return s.modify(ctx, code, ifVersions, func(link *model.Link) error {
+		before := len(link.Rules)
+		link.Rules = slices.DeleteFunc(slices.Clone(link.Rules), func(rule model.LinkRule) bool {
+			return rule.ID == ruleID
+		})
+		if len(link.Rules) == before {
+			return ErrRuleNotFound
+		}
+		return nil
+	})
+}
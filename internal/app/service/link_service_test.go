@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -11,10 +12,18 @@ import (
 )
 
 type mockLinkRepository struct {
-	createFn func(ctx context.Context, link *model.Link) error
-	getFn    func(ctx context.Context, code string) (*model.Link, error)
-	listFn   func(ctx context.Context, limit, offset int) ([]model.Link, error)
-	updateFn func(ctx context.Context, link *model.Link) error
+	createFn   func(ctx context.Context, link *model.Link) error
+	getFn      func(ctx context.Context, code string) (*model.Link, error)
+	listFn     func(ctx context.Context, limit, offset int) ([]model.Link, error)
+	updateFn   func(ctx context.Context, link *model.Link) error
+	seqFn      func(ctx context.Context) (int64, error)
+	purgeFn    func(ctx context.Context, code string, until time.Time) error
+	batchFn    func(ctx context.Context, links []*model.Link, atomic bool) ([]error, error)
+	queryFn    func(ctx context.Context, query repository.LinkQuery) (*repository.LinkPage, error)
+	afterFn    func(ctx context.Context, afterCode string, limit int) ([]model.Link, error)
+	flagFn     func(ctx context.Context, code, reason, action string) error
+	healthFn   func(ctx context.Context, code, url string, health model.LinkHealth) error
+	revisionFn func(ctx context.Context, code string, revisionID uint) (*model.LinkRevision, error)
 }
 
 func (m *mockLinkRepository) Create(ctx context.Context, link *model.Link) error {
@@ -24,6 +33,13 @@ func (m *mockLinkRepository) Create(ctx context.Context, link *model.Link) error
 	return nil
 }
 
+func (m *mockLinkRepository) CreateBatch(ctx context.Context, links []*model.Link, atomic bool) ([]error, error) {
+	if m.batchFn != nil {
+		return m.batchFn(ctx, links, atomic)
+	}
+	return make([]error, len(links)), nil
+}
+
 func (m *mockLinkRepository) GetByCode(ctx context.Context, code string) (*model.Link, error) {
 	if m.getFn != nil {
 		return m.getFn(ctx, code)
@@ -31,6 +47,14 @@ func (m *mockLinkRepository) GetByCode(ctx context.Context, code string) (*model
 	return nil, repository.ErrLinkNotFound
 }
 
+func (m *mockLinkRepository) GetIncludingDeleted(ctx context.Context, code string) (*model.Link, error) {
+	return m.GetByCode(ctx, code)
+}
+
+func (m *mockLinkRepository) GetByCodeUncached(ctx context.Context, code string) (*model.Link, error) {
+	return m.GetByCode(ctx, code)
+}
+
 func (m *mockLinkRepository) List(ctx context.Context, limit, offset int) ([]model.Link, error) {
 	if m.listFn != nil {
 		return m.listFn(ctx, limit, offset)
@@ -38,6 +62,13 @@ func (m *mockLinkRepository) List(ctx context.Context, limit, offset int) ([]mod
 	return nil, nil
 }
 
+func (m *mockLinkRepository) Query(ctx context.Context, query repository.LinkQuery) (*repository.LinkPage, error) {
+	if m.queryFn != nil {
+		return m.queryFn(ctx, query)
+	}
+	return &repository.LinkPage{}, nil
+}
+
 func (m *mockLinkRepository) Update(ctx context.Context, link *model.Link) error {
 	if m.updateFn != nil {
 		return m.updateFn(ctx, link)
@@ -45,6 +76,94 @@ func (m *mockLinkRepository) Update(ctx context.Context, link *model.Link) error
 	return nil
 }
 
+func (m *mockLinkRepository) NextCodeSequence(ctx context.Context) (int64, error) {
+	if m.seqFn != nil {
+		return m.seqFn(ctx)
+	}
+	return 0, nil
+}
+
+func (m *mockLinkRepository) Delete(ctx context.Context, code string) error {
+	return nil
+}
+
+func (m *mockLinkRepository) Restore(ctx context.Context, code string) (*model.Link, error) {
+	return &model.Link{Code: code}, nil
+}
+
+func (m *mockLinkRepository) ListDeleted(ctx context.Context, workspaceID string, limit, offset int) ([]model.Link, error) {
+	return nil, nil
+}
+
+func (m *mockLinkRepository) Purge(ctx context.Context, code string, until time.Time) error {
+	if m.purgeFn != nil {
+		return m.purgeFn(ctx, code, until)
+	}
+	return nil
+}
+
+func (m *mockLinkRepository) ListRevisions(ctx context.Context, code string, limit, offset int) ([]model.LinkRevision, error) {
+	return nil, nil
+}
+
+func (m *mockLinkRepository) GetRevision(ctx context.Context, code string, revisionID uint) (*model.LinkRevision, error) {
+	if m.revisionFn != nil {
+		return m.revisionFn(ctx, code, revisionID)
+	}
+	return nil, repository.ErrRevisionNotFound
+}
+
+func (m *mockLinkRepository) RevertToRevision(ctx context.Context, link *model.Link, revisionID uint) error {
+	if m.updateFn != nil {
+		return m.updateFn(ctx, link)
+	}
+	return nil
+}
+
+func (m *mockLinkRepository) ListAfter(ctx context.Context, afterCode string, limit int) ([]model.Link, error) {
+	if m.afterFn != nil {
+		return m.afterFn(ctx, afterCode, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockLinkRepository) Flag(ctx context.Context, code, reason, action string) error {
+	if m.flagFn != nil {
+		return m.flagFn(ctx, code, reason, action)
+	}
+	return nil
+}
+
+func (m *mockLinkRepository) ClearFlag(ctx context.Context, code string) (*model.Link, error) {
+	return m.GetByCode(ctx, code)
+}
+
+func (m *mockLinkRepository) ListFlagged(ctx context.Context, limit, offset int) ([]model.Link, error) {
+	return nil, nil
+}
+
+func (m *mockLinkRepository) UpdatePreview(ctx context.Context, code string, preview model.LinkPreview) error {
+	return nil
+}
+
+func (m *mockLinkRepository) UpdateHealth(ctx context.Context, code, url string, health model.LinkHealth) error {
+	if m.healthFn != nil {
+		return m.healthFn(ctx, code, url, health)
+	}
+	return nil
+}
+
+type stubCodeGenerator struct {
+	codes []string
+	calls int
+}
+
+func (g *stubCodeGenerator) Generate(ctx context.Context) (string, error) {
+	code := g.codes[g.calls%len(g.codes)]
+	g.calls++
+	return code, nil
+}
+
 func TestLinkService_CreateLink(t *testing.T) {
 	repo := &mockLinkRepository{
 		createFn: func(ctx context.Context, link *model.Link) error {
@@ -66,6 +185,66 @@ func TestLinkService_CreateLink(t *testing.T) {
 	}
 }
 
+func TestLinkService_CreateLink_GeneratesCode(t *testing.T) {
+	repo := &mockLinkRepository{
+		createFn: func(ctx context.Context, link *model.Link) error {
+			if link.Code == "taken" {
+				return repository.ErrDuplicateCode
+			}
+			return nil
+		},
+	}
+	gen := &stubCodeGenerator{codes: []string{"taken", "fresh"}}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, CodeGenerator: gen})
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateLink returned error: %v", err)
+	}
+	if link.Code != "fresh" {
+		t.Fatalf("expected retry to yield code fresh, got %s", link.Code)
+	}
+	if gen.calls != 2 {
+		t.Fatalf("expected 2 generator calls, got %d", gen.calls)
+	}
+}
+
+func TestLinkService_CreateLink_GenerationExhausted(t *testing.T) {
+	repo := &mockLinkRepository{
+		createFn: func(ctx context.Context, link *model.Link) error {
+			return repository.ErrDuplicateCode
+		},
+	}
+	gen := &stubCodeGenerator{codes: []string{"taken"}}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, CodeGenerator: gen, MaxCodeAttempts: 3})
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com"})
+	if !errors.Is(err, ErrCodeGenerationExhausted) {
+		t.Fatalf("expected ErrCodeGenerationExhausted, got %v", err)
+	}
+	if gen.calls != 3 {
+		t.Fatalf("expected 3 generator calls, got %d", gen.calls)
+	}
+}
+
+func TestLinkService_CreateLink_CustomCodeConflict(t *testing.T) {
+	repo := &mockLinkRepository{
+		createFn: func(ctx context.Context, link *model.Link) error {
+			return repository.ErrDuplicateCode
+		},
+	}
+	gen := &stubCodeGenerator{codes: []string{"unused"}}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, CodeGenerator: gen})
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{Code: "mine", URL: "https://example.com"})
+	if !errors.Is(err, repository.ErrDuplicateCode) {
+		t.Fatalf("expected ErrDuplicateCode, got %v", err)
+	}
+	if gen.calls != 0 {
+		t.Fatalf("expected no generator calls for custom code, got %d", gen.calls)
+	}
+}
+
 func TestLinkService_GetLink_NotFound(t *testing.T) {
 	repo := &mockLinkRepository{
 		getFn: func(ctx context.Context, code string) (*model.Link, error) {
@@ -124,3 +303,485 @@ func TestLinkService_UpdateLink(t *testing.T) {
 		t.Fatalf("UpdateLink error: %v", err)
 	}
 }
+
+func TestLinkService_PurgeLink_Quarantine(t *testing.T) {
+	var quarantinedUntil time.Time
+	repo := &mockLinkRepository{
+		purgeFn: func(ctx context.Context, code string, until time.Time) error {
+			quarantinedUntil = until
+			return nil
+		},
+	}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, QuarantinePeriod: 48 * time.Hour})
+	if err := svc.PurgeLink(context.Background(), "gone"); err != nil {
+		t.Fatalf("PurgeLink error: %v", err)
+	}
+
+	expected := time.Now().Add(48 * time.Hour)
+	if quarantinedUntil.Before(expected.Add(-time.Minute)) || quarantinedUntil.After(expected.Add(time.Minute)) {
+		t.Fatalf("expected quarantine until ~%s, got %s", expected, quarantinedUntil)
+	}
+}
+
+func TestLinkService_CreateLink_SkipsQuarantinedGeneratedCode(t *testing.T) {
+	repo := &mockLinkRepository{
+		createFn: func(ctx context.Context, link *model.Link) error {
+			if link.Code == "old" {
+				return repository.ErrCodeQuarantined
+			}
+			return nil
+		},
+	}
+	gen := &stubCodeGenerator{codes: []string{"old", "new"}}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, CodeGenerator: gen})
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateLink returned error: %v", err)
+	}
+	if link.Code != "new" {
+		t.Fatalf("expected quarantined code to be skipped, got %s", link.Code)
+	}
+}
+
+func TestLinkService_BulkCreateLinks_PartialFailure(t *testing.T) {
+	var batches [][]string
+	repo := &mockLinkRepository{
+		batchFn: func(ctx context.Context, links []*model.Link, atomic bool) ([]error, error) {
+			codes := make([]string, len(links))
+			errs := make([]error, len(links))
+			for i, link := range links {
+				codes[i] = link.Code
+				if link.Code == "taken" || link.Code == "gen-1" {
+					errs[i] = repository.ErrDuplicateCode
+				}
+			}
+			batches = append(batches, codes)
+			return errs, nil
+		},
+	}
+	gen := &stubCodeGenerator{codes: []string{"gen-1", "gen-2"}}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, CodeGenerator: gen})
+	results, err := svc.BulkCreateLinks(context.Background(), []CreateLinkInput{
+		{Code: "first", URL: "https://example.com/1"},
+		{Code: "taken", URL: "https://example.com/2"},
+		{URL: "https://example.com/3"},
+		{Code: "a/b", URL: "https://example.com/4"},
+	}, false)
+	if err != nil {
+		t.Fatalf("BulkCreateLinks error: %v", err)
+	}
+
+	if results[0].Err != nil || results[0].Link.Code != "first" {
+		t.Fatalf("expected row 1 to be created, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, repository.ErrDuplicateCode) {
+		t.Fatalf("expected row 2 to conflict, got %v", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].Link.Code != "gen-2" {
+		t.Fatalf("expected row 3 to be created with a regenerated code, got %+v", results[2])
+	}
+	var validationErr *ValidationError
+	if !errors.As(results[3].Err, &validationErr) {
+		t.Fatalf("expected row 4 to fail validation, got %v", results[3].Err)
+	}
+	if len(batches) != 2 || len(batches[1]) != 1 || batches[1][0] != "gen-2" {
+		t.Fatalf("expected a retry batch with only the regenerated code, got %v", batches)
+	}
+}
+
+func TestLinkService_BulkCreateLinks_AllOrNothing(t *testing.T) {
+	repo := &mockLinkRepository{
+		batchFn: func(ctx context.Context, links []*model.Link, atomic bool) ([]error, error) {
+			if !atomic {
+				t.Fatal("expected an atomic batch")
+			}
+			errs := make([]error, len(links))
+			errs[1] = repository.ErrDuplicateCode
+			return errs, repository.ErrBatchAborted
+		},
+	}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo})
+	results, err := svc.BulkCreateLinks(context.Background(), []CreateLinkInput{
+		{Code: "one", URL: "https://example.com/1"},
+		{Code: "two", URL: "https://example.com/2"},
+	}, true)
+	if !errors.Is(err, ErrBulkAborted) {
+		t.Fatalf("expected ErrBulkAborted, got %v", err)
+	}
+	if !errors.Is(results[0].Err, ErrBulkAborted) || results[0].Link != nil {
+		t.Fatalf("expected row 1 to be aborted, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, repository.ErrDuplicateCode) {
+		t.Fatalf("expected row 2 to conflict, got %v", results[1].Err)
+	}
+}
+
+func TestLinkService_BulkCreateLinks_AllOrNothingAbortWithoutRowError(t *testing.T) {
+	repo := &mockLinkRepository{
+		batchFn: func(ctx context.Context, links []*model.Link, atomic bool) ([]error, error) {
+			// A concurrent insert won the race: the batch was rolled back but no row is to blame.
+			return make([]error, len(links)), fmt.Errorf("%w: %w", repository.ErrBatchAborted, repository.ErrDuplicateCode)
+		},
+	}
+
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo})
+	results, err := svc.BulkCreateLinks(context.Background(), []CreateLinkInput{
+		{Code: "one", URL: "https://example.com/1"},
+		{Code: "two", URL: "https://example.com/2"},
+	}, true)
+	if !errors.Is(err, ErrBulkAborted) {
+		t.Fatalf("expected ErrBulkAborted, got %v", err)
+	}
+	for i, result := range results {
+		if result.Link != nil || !errors.Is(result.Err, repository.ErrBatchAborted) {
+			t.Fatalf("expected row %d to be aborted, got %+v", i+1, result)
+		}
+	}
+}
+
+func TestLinkService_ScopesLinksToActorWorkspace(t *testing.T) {
+	stored := &model.Link{Code: "team-a", URL: "https://a.example", WorkspaceID: "ws-a"}
+	var updated bool
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			copied := *stored
+			return &copied, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			updated = true
+			return nil
+		},
+		queryFn: func(ctx context.Context, query repository.LinkQuery) (*repository.LinkPage, error) {
+			if query.WorkspaceID != "ws-b" {
+				t.Fatalf("expected query scoped to ws-b, got %q", query.WorkspaceID)
+			}
+			return &repository.LinkPage{}, nil
+		},
+	}
+	svc := NewLinkService(repo)
+	ctx := WithActor(context.Background(), Actor{WorkspaceID: "ws-b", OwnerID: "bob"})
+
+	if _, err := svc.GetLink(ctx, "team-a"); !errors.Is(err, repository.ErrLinkNotFound) {
+		t.Fatalf("expected ErrLinkNotFound for another workspace's link, got %v", err)
+	}
+	url := "https://evil.example"
+	if _, err := svc.UpdateLink(ctx, "team-a", UpdateLinkInput{URL: &url}); !errors.Is(err, repository.ErrLinkNotFound) {
+		t.Fatalf("expected ErrLinkNotFound on update, got %v", err)
+	}
+	if updated {
+		t.Fatalf("expected link in another workspace to stay untouched")
+	}
+	if _, err := svc.QueryLinks(ctx, repository.LinkQuery{}); err != nil {
+		t.Fatalf("QueryLinks error: %v", err)
+	}
+
+	owner := WithActor(context.Background(), Actor{WorkspaceID: "ws-a"})
+	if _, err := svc.GetLink(owner, "team-a"); err != nil {
+		t.Fatalf("expected owning workspace to read the link, got %v", err)
+	}
+
+	created, err := svc.CreateLink(ctx, CreateLinkInput{Code: "bobs-link", URL: "https://b.example"})
+	if err != nil {
+		t.Fatalf("CreateLink error: %v", err)
+	}
+	if created.WorkspaceID != "ws-b" || created.OwnerID != "bob" {
+		t.Fatalf("expected link owned by bob in ws-b, got %q/%q", created.WorkspaceID, created.OwnerID)
+	}
+}
+
+func TestLinkService_UpdateLink_IfVersionMismatch(t *testing.T) {
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", Version: 3}, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			t.Fatalf("update must not be attempted on a version mismatch")
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	url := "https://example.org"
+	_, err := svc.UpdateLink(context.Background(), "abc", UpdateLinkInput{URL: &url, IfVersions: []int{1, 2}})
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+}
+
+func TestLinkService_UpdateLink_RetriesUnconditionalConflict(t *testing.T) {
+	version := 1
+	updates := 0
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", Version: version}, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			updates++
+			if updates == 1 {
+				// Another writer got in between the read and the write.
+				version++
+				return repository.ErrVersionConflict
+			}
+			if link.Version != version {
+				t.Fatalf("expected retry to write on top of version %d, got %d", version, link.Version)
+			}
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	disabled := true
+	if _, err := svc.UpdateLink(context.Background(), "abc", UpdateLinkInput{Disabled: &disabled}); err != nil {
+		t.Fatalf("UpdateLink error: %v", err)
+	}
+	if updates != 2 {
+		t.Fatalf("expected 2 update attempts, got %d", updates)
+	}
+}
+
+type stubClickCounter struct {
+	counts map[string]int64
+	err    error // returned by Current when set
+}
+
+func (s *stubClickCounter) Current(ctx context.Context, code string) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.counts[code], nil
+}
+
+func (s *stubClickCounter) Claim(ctx context.Context, code string, limit int) (int64, bool, error) {
+	if limit > 0 && s.counts[code] >= int64(limit) {
+		return s.counts[code], false, nil
+	}
+	s.counts[code]++
+	return s.counts[code], true, nil
+}
+
+func (s *stubClickCounter) ClaimOnce(ctx context.Context, code, clickID string, limit int, ttl time.Duration) (int64, bool, error) {
+	return s.Claim(ctx, code, limit)
+}
+
+func (s *stubClickCounter) Reset(ctx context.Context, code string) error {
+	s.counts[code] = 0
+	return nil
+}
+
+func (s *stubClickCounter) Forget(ctx context.Context, code string) error {
+	delete(s.counts, code)
+	return nil
+}
+
+func (s *stubClickCounter) Flush(ctx context.Context, batch int) (int, error) {
+	return 0, nil
+}
+
+func TestLinkService_ClickCountIsLiveAndResettable(t *testing.T) {
+	maxClicks := 1
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", MaxClicks: &maxClicks}, nil
+		},
+	}
+	counter := &stubClickCounter{counts: map[string]int64{"invite": 1}}
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, ClickCounter: counter})
+
+	link, err := svc.GetLink(context.Background(), "invite")
+	if err != nil {
+		t.Fatalf("GetLink error: %v", err)
+	}
+	if link.ClickCount != 1 || !link.ClickCapped(link.ClickCount) {
+		t.Fatalf("expected capped link with 1 click, got count %d", link.ClickCount)
+	}
+
+	link, err = svc.ResetClickCount(context.Background(), "invite")
+	if err != nil {
+		t.Fatalf("ResetClickCount error: %v", err)
+	}
+	if link.ClickCount != 0 || counter.counts["invite"] != 0 {
+		t.Fatalf("expected counter reset to zero")
+	}
+}
+
+func TestLinkService_GetLink_FallsBackToStoredClickCount(t *testing.T) {
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", ClickCount: 7}, nil
+		},
+	}
+	counter := &stubClickCounter{err: errors.New("redis unavailable")}
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{Repo: repo, ClickCounter: counter})
+
+	link, err := svc.GetLink(context.Background(), "invite")
+	if err != nil {
+		t.Fatalf("GetLink error: %v", err)
+	}
+	if link.ClickCount != 7 {
+		t.Fatalf("expected the stored click count 7, got %d", link.ClickCount)
+	}
+}
+
+func TestLinkService_RevertLink_AppliesURLPolicy(t *testing.T) {
+	revisions := map[uint]model.LinkRevision{
+		1: {ID: 1, LinkCode: "promo", URL: "http://127.0.0.1/admin", Mode: "direct"},
+		2: {ID: 2, LinkCode: "promo", URL: "https://example.com/old", Mode: "click"},
+	}
+	var saved *model.Link
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com/new", Mode: "direct", Version: 3}, nil
+		},
+		revisionFn: func(ctx context.Context, code string, revisionID uint) (*model.LinkRevision, error) {
+			revision, ok := revisions[revisionID]
+			if !ok {
+				return nil, repository.ErrRevisionNotFound
+			}
+			return &revision, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			saved = link
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	_, err := svc.RevertLink(context.Background(), "promo", 1, nil)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "url" {
+		t.Fatalf("expected the reverted URL to be rejected, got %v", err)
+	}
+	if saved != nil {
+		t.Fatal("expected a rejected revert not to be saved")
+	}
+
+	if _, err := svc.RevertLink(context.Background(), "promo", 9, nil); !errors.Is(err, repository.ErrRevisionNotFound) {
+		t.Fatalf("expected ErrRevisionNotFound, got %v", err)
+	}
+
+	if _, err := svc.RevertLink(context.Background(), "promo", 2, []int{2}); !errors.Is(err, repository.ErrVersionConflict) || saved != nil {
+		t.Fatalf("expected a stale If-Match to conflict without saving, got %v", err)
+	}
+
+	link, err := svc.RevertLink(context.Background(), "promo", 2, []int{3})
+	if err != nil {
+		t.Fatalf("RevertLink error: %v", err)
+	}
+	if saved == nil || link.URL != "https://example.com/old" || link.Mode != "click" || link.Version != 3 {
+		t.Fatalf("expected revision 2 saved over version 3, got %+v", link)
+	}
+}
+
+func TestLinkService_UpdateLink_ZeroMaxClicksRemovesCap(t *testing.T) {
+	maxClicks := 5
+	var saved *model.Link
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", MaxClicks: &maxClicks, Version: 1}, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			saved = link
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	zero := 0
+	if _, err := svc.UpdateLink(context.Background(), "abc", UpdateLinkInput{MaxClicks: &zero}); err != nil {
+		t.Fatalf("UpdateLink error: %v", err)
+	}
+	if saved == nil || saved.MaxClicks != nil {
+		t.Fatalf("expected max_clicks to be cleared")
+	}
+}
+
+func TestLinkService_CreateLink_RejectsEmptyActivationWindow(t *testing.T) {
+	svc := NewLinkService(&mockLinkRepository{})
+
+	startsAt := time.Now().Add(2 * time.Hour)
+	expiresAt := startsAt.Add(-time.Hour)
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{
+		Code:      "launch",
+		URL:       "https://example.com",
+		StartsAt:  &startsAt,
+		ExpiresAt: &expiresAt,
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "starts_at" {
+		t.Fatalf("expected starts_at validation error, got %v", err)
+	}
+}
+
+func TestLinkService_CreateLink_HashesPassword(t *testing.T) {
+	svc := NewLinkService(&mockLinkRepository{})
+
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{
+		Code:     "secret",
+		URL:      "https://example.com",
+		Mode:     ModePassword,
+		Password: "hunter2",
+	})
+	if err != nil {
+		t.Fatalf("CreateLink error: %v", err)
+	}
+	if link.PasswordHash == "" || link.PasswordHash == "hunter2" {
+		t.Fatalf("expected a bcrypt hash, got %q", link.PasswordHash)
+	}
+	if !CheckLinkPassword(link, "hunter2") || CheckLinkPassword(link, "hunter3") {
+		t.Fatalf("password check does not match the stored hash")
+	}
+}
+
+func TestLinkService_CreateLink_PasswordModeRequiresPassword(t *testing.T) {
+	svc := NewLinkService(&mockLinkRepository{})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{
+		Code: "secret",
+		URL:  "https://example.com",
+		Mode: ModePassword,
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "password" {
+		t.Fatalf("expected password validation error, got %v", err)
+	}
+}
+
+func TestLinkService_UpdateLink_LeavingPasswordModeClearsHash(t *testing.T) {
+	hash, err := HashLinkPassword("hunter2")
+	if err != nil {
+		t.Fatalf("HashLinkPassword error: %v", err)
+	}
+	var saved *model.Link
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", Mode: ModePassword, PasswordHash: hash, Version: 1}, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			saved = link
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	url := "https://example.org"
+	if _, err := svc.UpdateLink(context.Background(), "abc", UpdateLinkInput{URL: &url}); err != nil {
+		t.Fatalf("UpdateLink error: %v", err)
+	}
+	if saved.PasswordHash != hash {
+		t.Fatalf("expected the password to survive unrelated updates")
+	}
+
+	mode := "direct"
+	if _, err := svc.UpdateLink(context.Background(), "abc", UpdateLinkInput{Mode: &mode}); err != nil {
+		t.Fatalf("UpdateLink error: %v", err)
+	}
+	if saved.PasswordHash != "" {
+		t.Fatalf("expected the password hash to be cleared")
+	}
+}
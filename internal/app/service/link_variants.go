@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+// maxLinkVariants bounds the destinations of a single A/B test.
+const maxLinkVariants = 20
+
+// maxVariantWeight keeps weights in a range that reads like percentages or shares.
+const maxVariantWeight = 10000
+
+// variantIDPattern keeps variant IDs usable in cookies, stats and redirect tokens.
+var variantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// AssignVariant returns the variant a visitor is sent to. The sticky variant from a
+// previous visit is kept while it exists and has weight; otherwise one is picked at
+// random in proportion to the weights. It returns nil when no variant has weight.
+func AssignVariant(variants model.LinkVariants, sticky string) *model.LinkVariant {
+	if sticky != "" {
+		if variant := variants.Variant(sticky); variant != nil && variant.Weight > 0 {
+			return variant
+		}
+	}
+	total := variants.TotalWeight()
+	if total <= 0 {
+		return nil
+	}
+	return variants.Pick(rand.IntN(total))
+}
+
+// validateVariants checks IDs, destinations and weights of an A/B variant list.
+func validateVariants(variants model.LinkVariants) error {
+	if len(variants) > maxLinkVariants {
+		return &ValidationError{Fields: []FieldError{{Field: "variants", Message: fmt.Sprintf("at most %d variants are allowed", maxLinkVariants)}}}
+	}
+
+	var fields []FieldError
+	seen := make(map[string]bool, len(variants))
+	for i := range variants {
+		variant := &variants[i]
+		prefix := fmt.Sprintf("variants[%d]", i)
+		switch {
+		case !variantIDPattern.MatchString(variant.ID):
+			fields = append(fields, FieldError{Field: prefix + ".id", Message: "must be 1-32 letters, digits, '-' or '_'"})
+		case seen[variant.ID]:
+			fields = append(fields, FieldError{Field: prefix + ".id", Message: "is used by another variant"})
+		}
+		seen[variant.ID] = true
+		if !validDestination(variant.Destination) {
+			fields = append(fields, FieldError{Field: prefix + ".destination", Message: "must be an absolute http(s) URL"})
+		} else if msg := validateDestinationTemplate(variant.Destination); msg != "" {
+			fields = append(fields, FieldError{Field: prefix + ".destination", Message: msg})
+		}
+		if variant.Weight < 0 || variant.Weight > maxVariantWeight {
+			fields = append(fields, FieldError{Field: prefix + ".weight", Message: fmt.Sprintf("must be between 0 and %d", maxVariantWeight)})
+		}
+	}
+	if len(variants) > 0 && len(fields) == 0 && variants.TotalWeight() == 0 {
+		fields = append(fields, FieldError{Field: "variants", Message: "at least one variant needs a positive weight"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+func TestAssignVariant(t *testing.T) {
+	variants := model.LinkVariants{
+		{ID: "control", Destination: "https://example.com/a", Weight: 3},
+		{ID: "paused", Destination: "https://example.com/b", Weight: 0},
+		{ID: "challenger", Destination: "https://example.com/c", Weight: 1},
+	}
+
+	if got := AssignVariant(variants, "challenger"); got == nil || got.ID != "challenger" {
+		t.Fatalf("expected the sticky variant to be kept, got %+v", got)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[AssignVariant(variants, "paused").ID]++
+	}
+	if counts["paused"] != 0 {
+		t.Fatalf("expected a paused variant to get no visitors, got %d", counts["paused"])
+	}
+	if share := float64(counts["control"]) / 4000; share < 0.7 || share > 0.8 {
+		t.Fatalf("expected control to get about 75%% of visitors, got %.2f", share)
+	}
+
+	if got := AssignVariant(model.LinkVariants{{ID: "off", Weight: 0}}, ""); got != nil {
+		t.Fatalf("expected no variant without weight, got %+v", got)
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	err := validateVariants(model.LinkVariants{
+		{ID: "a", Destination: "https://example.com/a", Weight: 1},
+		{ID: "a", Destination: "example.com", Weight: -1},
+		{ID: "bad:id", Destination: "https://example.com/c", Weight: 1},
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 4 {
+		t.Fatalf("expected duplicate id, destination, weight and id pattern errors, got %v", err)
+	}
+
+	err = validateVariants(model.LinkVariants{{ID: "a", Destination: "https://example.com/a"}})
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "variants" {
+		t.Fatalf("expected an error for variants without weight, got %v", err)
+	}
+}
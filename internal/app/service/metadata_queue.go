@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	apprepository "github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	metadataMaxDeliver = 3
+	metadataRetryDelay = time.Minute
+	metadataStreamAge  = 24 * time.Hour
+)
+
+// MetadataRequester queues fetching the preview of a link's URL.
+type MetadataRequester interface {
+	RequestMetadata(code, url string)
+}
+
+// MetadataPublisher queues metadata fetches on NATS JetStream, so pages are
+// fetched by MetadataConsumer outside the request path.
+type MetadataPublisher struct {
+	js     nats.JetStreamContext
+	logger *zap.Logger
+}
+
+// NewMetadataPublisher creates a new metadata fetch publisher.
+func NewMetadataPublisher(js nats.JetStreamContext, logger *zap.Logger) *MetadataPublisher {
+	return &MetadataPublisher{js: js, logger: logger}
+}
+
+// RequestMetadata publishes a fetch request in the background; failures are logged.
+func (p *MetadataPublisher) RequestMetadata(code, url string) {
+	data, err := json.Marshal(model.MetadataFetchRequest{Code: code, URL: url})
+	if err != nil {
+		p.logger.Error("failed to encode metadata fetch request", zap.Error(err), zap.String("code", code))
+		return
+	}
+	go func() {
+		if _, err := p.js.Publish(model.MetadataStreamSubject, data); err != nil {
+			p.logger.Error("failed to queue metadata fetch", zap.Error(err), zap.String("code", code))
+		}
+	}()
+}
+
+// MetadataConsumer fetches queued destination pages and stores their previews.
+type MetadataConsumer struct {
+	js      nats.JetStreamContext
+	logger  *zap.Logger
+	repo    apprepository.LinkRepository
+	fetcher *MetadataFetcher
+}
+
+// NewMetadataConsumer creates a new metadata fetch consumer.
+func NewMetadataConsumer(js nats.JetStreamContext, logger *zap.Logger, repo apprepository.LinkRepository, fetcher *MetadataFetcher) *MetadataConsumer {
+	return &MetadataConsumer{js: js, logger: logger, repo: repo, fetcher: fetcher}
+}
+
+// Start creates the stream and durable consumer when missing and begins fetching.
+func (c *MetadataConsumer) Start() error {
+	if _, err := c.js.StreamInfo(model.MetadataStreamName); err != nil {
+		_, err = c.js.AddStream(&nats.StreamConfig{
+			Name:     model.MetadataStreamName,
+			Subjects: []string{model.MetadataStreamSubject},
+			MaxAge:   metadataStreamAge,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create metadata stream: %w", err)
+		}
+	}
+
+	if _, err := c.js.ConsumerInfo(model.MetadataStreamName, model.MetadataConsumerName); err != nil {
+		_, err = c.js.AddConsumer(model.MetadataStreamName, &nats.ConsumerConfig{
+			Durable:    model.MetadataConsumerName,
+			AckPolicy:  nats.AckExplicitPolicy,
+			MaxDeliver: metadataMaxDeliver,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create metadata consumer: %w", err)
+		}
+	}
+
+	sub, err := c.js.PullSubscribe(model.MetadataStreamSubject, model.MetadataConsumerName)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to metadata requests: %w", err)
+	}
+
+	go c.consume(sub)
+	return nil
+}
+
+func (c *MetadataConsumer) consume(sub *nats.Subscription) {
+	ctx := context.Background()
+	for {
+		msgs, err := sub.Fetch(10, nats.MaxWait(5*time.Second))
+		if err != nil && err != nats.ErrTimeout {
+			c.logger.Error("failed to fetch metadata requests", zap.Error(err))
+			continue
+		}
+
+		for _, msg := range msgs {
+			var req model.MetadataFetchRequest
+			if err := json.Unmarshal(msg.Data, &req); err != nil {
+				c.logger.Error("failed to unmarshal metadata request", zap.Error(err))
+				msg.Term()
+				continue
+			}
+
+			if err := c.process(ctx, req); err != nil {
+				c.logger.Warn("failed to fetch link metadata",
+					zap.String("code", req.Code),
+					zap.String("url", req.URL),
+					zap.Error(err))
+				msg.NakWithDelay(metadataRetryDelay)
+				continue
+			}
+			msg.Ack()
+		}
+	}
+}
+
+func (c *MetadataConsumer) process(ctx context.Context, req model.MetadataFetchRequest) error {
+	preview, err := c.fetcher.Fetch(ctx, ExpandDestination(req.URL, TemplateVars{Code: req.Code}))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	preview.URL = req.URL
+	preview.FetchedAt = &now
+
+	err = c.repo.UpdatePreview(ctx, req.Code, preview)
+	if errors.Is(err, apprepository.ErrLinkNotFound) {
+		// The link was deleted or points elsewhere by now; a newer request covers it.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.logger.Debug("stored link metadata", zap.String("code", req.Code), zap.String("title", preview.Title))
+	return nil
+}
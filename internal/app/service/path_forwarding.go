@@ -0,0 +1,67 @@
+package service
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// maxForwardedPathLen bounds the path suffix carried through redirect tokens.
+const maxForwardedPathLen = 1024
+
+// CleanForwardedPath normalizes the path a visitor appended to a prefix link's code
+// into an escaped suffix starting with "/". Dot segments are resolved within the
+// suffix, so it cannot climb above the destination's path. ok is false for suffixes
+// that cannot be forwarded.
+func CleanForwardedPath(raw string) (suffix string, ok bool) {
+	if raw == "" {
+		return "", true
+	}
+	if len(raw) > maxForwardedPathLen {
+		return "", false
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return "", false
+	}
+	for i := 0; i < len(decoded); i++ {
+		if decoded[i] < 0x20 || decoded[i] == 0x7f {
+			return "", false
+		}
+	}
+
+	cleaned := path.Clean("/" + decoded)
+	if cleaned == "/" {
+		return "", true
+	}
+	if strings.HasSuffix(decoded, "/") {
+		cleaned += "/"
+	}
+
+	segments := strings.Split(cleaned, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/"), true
+}
+
+// JoinDestinationPath appends a suffix from CleanForwardedPath to the path of
+// destination, keeping the destination's query and fragment.
+func JoinDestinationPath(destination, suffix string) string {
+	if suffix == "" {
+		return destination
+	}
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+
+	joined := strings.TrimSuffix(u.EscapedPath(), "/") + suffix
+	unescaped, err := url.PathUnescape(joined)
+	if err != nil {
+		return destination
+	}
+	u.Path = unescaped
+	u.RawPath = joined
+	return u.String()
+}
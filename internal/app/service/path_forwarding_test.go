@@ -0,0 +1,44 @@
+package service
+
+import "testing"
+
+func TestCleanForwardedPath(t *testing.T) {
+	cases := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{"docs/getting-started", "/docs/getting-started", true},
+		{"docs/", "/docs/", true},
+		{"../../etc/passwd", "/etc/passwd", true},
+		{"a/%2e%2e/%2e%2e/b", "/b", true},
+		{"a b/c%3Fd", "/a%20b/c%3Fd", true},
+		{"..", "", true},
+		{"a%00b", "", false},
+		{"%zz", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := CleanForwardedPath(tc.raw)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("%q: expected (%q, %v), got (%q, %v)", tc.raw, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+func TestJoinDestinationPath(t *testing.T) {
+	cases := []struct {
+		destination string
+		suffix      string
+		want        string
+	}{
+		{"https://docs.example.com", "/guide", "https://docs.example.com/guide"},
+		{"https://docs.example.com/v2/", "/guide/", "https://docs.example.com/v2/guide/"},
+		{"https://docs.example.com/v2?ref=short#top", "/a%20b", "https://docs.example.com/v2/a%20b?ref=short#top"},
+		{"https://docs.example.com/v2", "", "https://docs.example.com/v2"},
+	}
+	for _, tc := range cases {
+		if got := JoinDestinationPath(tc.destination, tc.suffix); got != tc.want {
+			t.Errorf("%s + %s: expected %q, got %q", tc.destination, tc.suffix, tc.want, got)
+		}
+	}
+}
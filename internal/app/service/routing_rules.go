@@ -0,0 +1,298 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+// Platforms recognised by RuleConditions.Platforms.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+)
+
+// maxLinkRules bounds the rules evaluated on every redirect of a link.
+const maxLinkRules = 50
+
+var ruleDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ruleIDPattern keeps rule IDs within the click events' rule_id column and usable
+// in redirect tokens; generated IDs are UUIDs.
+var ruleIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+// geoKeyPattern matches ISO 3166-1 alpha-2 countries and ISO 3166-2 subdivisions.
+var geoKeyPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// RuleRequest holds the request attributes routing rules are matched against.
+type RuleRequest struct {
+	UserAgent      string
+	AcceptLanguage string
+	Referrer       string
+	Query          map[string]string
+	// Now is the request time in the timezone rules are written for.
+	Now time.Time
+}
+
+// MatchRule returns the first rule whose conditions all hold for req, or nil.
+func MatchRule(rules []model.LinkRule, req RuleRequest) *model.LinkRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var (
+		platform  = DetectPlatform(req.UserAgent)
+		languages = parseAcceptLanguage(req.AcceptLanguage)
+		referrer  = referrerHost(req.Referrer)
+	)
+	for i := range rules {
+		cond := &rules[i].Conditions
+		if len(cond.Platforms) > 0 && !slices.Contains(cond.Platforms, platform) {
+			continue
+		}
+		if len(cond.Languages) > 0 && !matchLanguage(cond.Languages, languages) {
+			continue
+		}
+		if !matchQuery(cond.Query, req.Query) {
+			continue
+		}
+		if len(cond.ReferrerHosts) > 0 && !matchHost(cond.ReferrerHosts, referrer) {
+			continue
+		}
+		if len(cond.Days) > 0 && !slices.Contains(cond.Days, ruleDays[req.Now.Weekday()]) {
+			continue
+		}
+		if !matchTimeOfDay(cond.TimeFrom, cond.TimeTo, req.Now) {
+			continue
+		}
+		return &rules[i]
+	}
+	return nil
+}
+
+// DetectPlatform classifies a User-Agent as PlatformIOS, PlatformAndroid or PlatformDesktop.
+func DetectPlatform(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return PlatformIOS
+	case strings.Contains(ua, "android"):
+		return PlatformAndroid
+	default:
+		return PlatformDesktop
+	}
+}
+
+// parseAcceptLanguage returns the lower-cased language tags of header, ignoring weights.
+func parseAcceptLanguage(header string) []string {
+	var tags []string
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && tag != "*" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// matchLanguage reports whether any accepted tag equals a wanted tag or is a
+// regional variant of it ("en" matches "en-GB", but "en-GB" does not match "en").
+func matchLanguage(wanted, accepted []string) bool {
+	for _, w := range wanted {
+		w = strings.ToLower(w)
+		for _, a := range accepted {
+			if a == w || strings.HasPrefix(a, w+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchQuery(wanted, query map[string]string) bool {
+	for key, want := range wanted {
+		got, ok := query[key]
+		if !ok || (want != "*" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func matchHost(wanted []string, host string) bool {
+	if host == "" {
+		return false
+	}
+	for _, w := range wanted {
+		w = strings.ToLower(w)
+		if host == w || strings.HasSuffix(host, "."+w) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTimeOfDay reports whether now falls in [from, to). A window whose end lies
+// before its start spans midnight; a missing bound is open.
+func matchTimeOfDay(from, to string, now time.Time) bool {
+	if from == "" && to == "" {
+		return true
+	}
+	minute := now.Hour()*60 + now.Minute()
+	start, _ := parseClock(from)
+	end, ok := parseClock(to)
+	if !ok {
+		end = 24 * 60
+	}
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock converts "HH:MM" to minutes past midnight.
+func parseClock(value string) (int, bool) {
+	if value == "" {
+		return 0, false
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// prepareRules assigns IDs to new rules and validates the list, returning a *ValidationError.
+func prepareRules(rules model.LinkRules) error {
+	if len(rules) > maxLinkRules {
+		return &ValidationError{Fields: []FieldError{{Field: "rules", Message: fmt.Sprintf("at most %d rules are allowed", maxLinkRules)}}}
+	}
+
+	var fields []FieldError
+	seen := make(map[string]struct{}, len(rules))
+	for i := range rules {
+		if rules[i].ID == "" {
+			rules[i].ID = uuid.New().String()
+		}
+		prefix := fmt.Sprintf("rules[%d]", i)
+		if !ruleIDPattern.MatchString(rules[i].ID) {
+			fields = append(fields, FieldError{Field: prefix + ".id", Message: "must be 1-36 letters, digits, '-' or '_'"})
+		} else if _, dup := seen[rules[i].ID]; dup {
+			fields = append(fields, FieldError{Field: prefix + ".id", Message: "is used by another rule"})
+		}
+		seen[rules[i].ID] = struct{}{}
+		fields = append(fields, validateRule(prefix, &rules[i])...)
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func validateRule(prefix string, rule *model.LinkRule) []FieldError {
+	var fields []FieldError
+	fail := func(field, message string) {
+		fields = append(fields, FieldError{Field: prefix + "." + field, Message: message})
+	}
+
+	if !validDestination(rule.Destination) {
+		fail("destination", "must be an absolute http(s) URL")
+	} else if msg := validateDestinationTemplate(rule.Destination); msg != "" {
+		fail("destination", msg)
+	}
+
+	cond := &rule.Conditions
+	for _, platform := range cond.Platforms {
+		if platform != PlatformIOS && platform != PlatformAndroid && platform != PlatformDesktop {
+			fail("conditions.platforms", "must contain only ios, android or desktop")
+			break
+		}
+	}
+	for _, day := range cond.Days {
+		if !slices.Contains(ruleDays, day) {
+			fail("conditions.days", "must contain only mon, tue, wed, thu, fri, sat or sun")
+			break
+		}
+	}
+	for _, bound := range []struct{ field, value string }{
+		{"conditions.time_from", cond.TimeFrom},
+		{"conditions.time_to", cond.TimeTo},
+	} {
+		if _, ok := parseClock(bound.value); bound.value != "" && !ok {
+			fail(bound.field, "must be a time of day as HH:MM")
+		}
+	}
+	for _, lang := range cond.Languages {
+		if strings.TrimSpace(lang) == "" {
+			fail("conditions.languages", "must not contain empty tags")
+			break
+		}
+	}
+	for _, host := range cond.ReferrerHosts {
+		if strings.TrimSpace(host) == "" || strings.ContainsAny(host, "/:") {
+			fail("conditions.referrer_hosts", "must contain bare host names")
+			break
+		}
+	}
+	return fields
+}
+
+// prepareGeoTargets upper-cases location keys and validates keys and destinations.
+func prepareGeoTargets(targets model.GeoTargets) (model.GeoTargets, error) {
+	if targets == nil {
+		return nil, nil
+	}
+
+	normalized := make(model.GeoTargets, len(targets))
+	var fields []FieldError
+	for key, destination := range targets {
+		upper := strings.ToUpper(strings.TrimSpace(key))
+		field := "geo_targets." + key
+		if !geoKeyPattern.MatchString(upper) {
+			fields = append(fields, FieldError{Field: field, Message: "must be an ISO 3166 country (DE) or region (US-CA) code"})
+			continue
+		}
+		if _, dup := normalized[upper]; dup {
+			fields = append(fields, FieldError{Field: field, Message: "is listed twice"})
+			continue
+		}
+		if !validDestination(destination) {
+			fields = append(fields, FieldError{Field: field, Message: "must be an absolute http(s) URL"})
+			continue
+		}
+		if msg := validateDestinationTemplate(destination); msg != "" {
+			fields = append(fields, FieldError{Field: field, Message: msg})
+			continue
+		}
+		normalized[upper] = destination
+	}
+	if len(fields) > 0 {
+		slices.SortFunc(fields, func(a, b FieldError) int { return strings.Compare(a.Field, b.Field) })
+		return nil, &ValidationError{Fields: fields}
+	}
+	return normalized, nil
+}
+
+func validDestination(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
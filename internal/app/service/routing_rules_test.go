@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile"
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0"
+)
+
+func TestMatchRule(t *testing.T) {
+	// Wednesday 2024-05-15 22:30 local time.
+	evening := time.Date(2024, 5, 15, 22, 30, 0, 0, time.UTC)
+	rules := []model.LinkRule{
+		{ID: "ios", Conditions: model.RuleConditions{Platforms: []string{PlatformIOS}}, Destination: "https://apps.apple.com/app"},
+		{ID: "android", Conditions: model.RuleConditions{Platforms: []string{PlatformAndroid}}, Destination: "https://play.google.com/app"},
+		{ID: "german", Conditions: model.RuleConditions{Languages: []string{"de"}}, Destination: "https://example.com/de"},
+		{ID: "campaign", Conditions: model.RuleConditions{Query: map[string]string{"utm_source": "*"}}, Destination: "https://example.com/c"},
+		{ID: "partner", Conditions: model.RuleConditions{ReferrerHosts: []string{"partner.io"}}, Destination: "https://example.com/p"},
+		{ID: "night", Conditions: model.RuleConditions{Days: []string{"wed"}, TimeFrom: "22:00", TimeTo: "06:00"}, Destination: "https://example.com/night"},
+	}
+
+	cases := []struct {
+		name string
+		req  RuleRequest
+		want string
+	}{
+		{"ios wins by order", RuleRequest{UserAgent: iPhoneUA, AcceptLanguage: "de-DE", Now: evening}, "ios"},
+		{"android", RuleRequest{UserAgent: androidUA}, "android"},
+		{"regional language variant", RuleRequest{UserAgent: desktopUA, AcceptLanguage: "fr;q=0.9, de-AT;q=0.8"}, "german"},
+		{"query presence", RuleRequest{UserAgent: desktopUA, Query: map[string]string{"utm_source": "mail"}}, "campaign"},
+		{"referrer subdomain", RuleRequest{UserAgent: desktopUA, Referrer: "https://blog.partner.io/post"}, "partner"},
+		{"lookalike referrer", RuleRequest{UserAgent: desktopUA, Referrer: "https://notpartner.io/", Now: evening.Add(-3 * time.Hour)}, ""},
+		{"window across midnight", RuleRequest{UserAgent: desktopUA, Now: evening}, "night"},
+		{"wrong day", RuleRequest{UserAgent: desktopUA, Now: evening.Add(24 * time.Hour)}, ""},
+	}
+	for _, tc := range cases {
+		got := ""
+		if rule := MatchRule(rules, tc.req); rule != nil {
+			got = rule.ID
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected rule %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestLinkService_AddRule_ValidatesAndAssignsID(t *testing.T) {
+	var saved *model.Link
+	repo := &mockLinkRepository{
+		getFn: func(ctx context.Context, code string) (*model.Link, error) {
+			return &model.Link{Code: code, URL: "https://example.com", Version: 1}, nil
+		},
+		updateFn: func(ctx context.Context, link *model.Link) error {
+			saved = link
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	_, err := svc.AddRule(context.Background(), "abc", model.LinkRule{
+		Conditions:  model.RuleConditions{TimeFrom: "25:00"},
+		Destination: "ftp://example.com",
+	}, -1, nil)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 {
+		t.Fatalf("expected destination and time_from validation errors, got %v", err)
+	}
+
+	link, err := svc.AddRule(context.Background(), "abc", model.LinkRule{
+		Conditions:  model.RuleConditions{Platforms: []string{PlatformIOS}},
+		Destination: "https://apps.apple.com/app",
+	}, -1, nil)
+	if err != nil {
+		t.Fatalf("AddRule error: %v", err)
+	}
+	if saved == nil || len(link.Rules) != 1 || link.Rules[0].ID == "" {
+		t.Fatalf("expected a stored rule with an id, got %+v", link.Rules)
+	}
+
+	_, err = svc.ReplaceRules(context.Background(), "abc", model.LinkRules{
+		{ID: "promo", Destination: "https://example.com/promo"},
+		{ID: "promo:" + strings.Repeat("x", 40), Destination: "https://example.com/other"},
+	}, nil)
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "rules[1].id" {
+		t.Fatalf("expected a rules[1].id validation error, got %v", err)
+	}
+
+	if _, err := svc.DeleteRule(context.Background(), "abc", "missing", nil); !errors.Is(err, ErrRuleNotFound) {
+		t.Fatalf("expected ErrRuleNotFound, got %v", err)
+	}
+}
+
+func TestPrepareGeoTargets(t *testing.T) {
+	targets, err := prepareGeoTargets(model.GeoTargets{"de": "https://example.de", "us-ca": "https://example.com/ca"})
+	if err != nil {
+		t.Fatalf("prepareGeoTargets error: %v", err)
+	}
+	if targets.Match("US", "US-CA") != "US-CA" || targets.Match("DE", "DE-BY") != "DE" || targets.Match("FR", "") != "" {
+		t.Fatalf("unexpected matches for %v", targets)
+	}
+
+	_, err = prepareGeoTargets(model.GeoTargets{"Germany": "https://example.de", "FR": "javascript:alert(1)"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 {
+		t.Fatalf("expected key and destination validation errors, got %v", err)
+	}
+}
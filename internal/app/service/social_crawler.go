@@ -0,0 +1,96 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+// Limits of the per-link social overrides, matching their columns.
+const (
+	maxSocialTitle       = 300
+	maxSocialDescription = 1000
+	maxSocialImageURL    = 2048
+)
+
+// DefaultSocialCrawlers are User-Agent substrings of link unfurlers that render
+// previews of shared links.
+var DefaultSocialCrawlers = []string{
+	"facebookexternalhit",
+	"facebookcatalog",
+	"Twitterbot",
+	"Slackbot",
+	"LinkedInBot",
+	"Discordbot",
+	"TelegramBot",
+	"WhatsApp",
+	"Pinterestbot",
+	"redditbot",
+	"Applebot",
+	"SkypeUriPreview",
+	"Embedly",
+	"vkShare",
+	"Iframely",
+	"Mastodon",
+}
+
+// CrawlerDetector recognises social crawlers by their User-Agent.
+type CrawlerDetector struct {
+	patterns []string
+}
+
+// NewCrawlerDetector matches User-Agents containing any of patterns, ignoring case.
+// Without patterns DefaultSocialCrawlers are used.
+func NewCrawlerDetector(patterns []string) *CrawlerDetector {
+	if len(patterns) == 0 {
+		patterns = DefaultSocialCrawlers
+	}
+	d := &CrawlerDetector{}
+	for _, p := range patterns {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			d.patterns = append(d.patterns, p)
+		}
+	}
+	return d
+}
+
+// IsCrawler reports whether userAgent belongs to a social crawler.
+func (d *CrawlerDetector) IsCrawler(userAgent string) bool {
+	if d == nil || userAgent == "" {
+		return false
+	}
+	ua := strings.ToLower(userAgent)
+	for _, p := range d.patterns {
+		if strings.Contains(ua, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareSocial trims the social overrides and checks them against their column limits.
+func prepareSocial(social *model.LinkSocial) *FieldError {
+	social.Title = strings.TrimSpace(social.Title)
+	social.Description = strings.TrimSpace(social.Description)
+	social.ImageURL = strings.TrimSpace(social.ImageURL)
+
+	if utf8.RuneCountInString(social.Title) > maxSocialTitle {
+		return &FieldError{Field: "social.title", Message: "must be at most 300 characters"}
+	}
+	if utf8.RuneCountInString(social.Description) > maxSocialDescription {
+		return &FieldError{Field: "social.description", Message: "must be at most 1000 characters"}
+	}
+	if social.ImageURL == "" {
+		return nil
+	}
+	if len(social.ImageURL) > maxSocialImageURL {
+		return &FieldError{Field: "social.image_url", Message: "must be at most 2048 characters"}
+	}
+	u, err := url.Parse(social.ImageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &FieldError{Field: "social.image_url", Message: "must be an absolute http or https URL"}
+	}
+	return nil
+}
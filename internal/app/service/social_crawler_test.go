@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+func TestCrawlerDetector_IsCrawler(t *testing.T) {
+	detector := NewCrawlerDetector(nil)
+	crawlers := []string{
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+		"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)",
+		"Twitterbot/1.0",
+	}
+	for _, ua := range crawlers {
+		if !detector.IsCrawler(ua) {
+			t.Errorf("expected %q to be a crawler", ua)
+		}
+	}
+	if detector.IsCrawler("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15") || detector.IsCrawler("") {
+		t.Error("expected browsers not to be crawlers")
+	}
+
+	custom := NewCrawlerDetector([]string{" MyUnfurler ", ""})
+	if !custom.IsCrawler("myunfurler/2") || custom.IsCrawler("Twitterbot/1.0") {
+		t.Error("expected only the configured patterns to match")
+	}
+
+	var disabled *CrawlerDetector
+	if disabled.IsCrawler("Twitterbot/1.0") {
+		t.Error("expected a nil detector to match nothing")
+	}
+}
+
+func TestLinkService_CreateLink_SocialOverrides(t *testing.T) {
+	svc := NewLinkService(&mockLinkRepository{})
+
+	link, err := svc.CreateLink(context.Background(), CreateLinkInput{
+		Code:   "launch",
+		URL:    "https://example.com",
+		Social: model.LinkSocial{Title: "  Launch day ", ImageURL: "https://cdn.example.com/card.png"},
+	})
+	if err != nil {
+		t.Fatalf("CreateLink error: %v", err)
+	}
+	if link.Social.Title != "Launch day" || link.Social.ImageURL != "https://cdn.example.com/card.png" {
+		t.Fatalf("unexpected social overrides: %+v", link.Social)
+	}
+
+	cases := map[string]model.LinkSocial{
+		"social.title":     {Title: strings.Repeat("a", maxSocialTitle+1)},
+		"social.image_url": {ImageURL: "javascript:alert(1)"},
+	}
+	for field, social := range cases {
+		_, err := svc.CreateLink(context.Background(), CreateLinkInput{URL: "https://example.com", Social: social})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != field {
+			t.Errorf("expected a %s validation error, got %v", field, err)
+		}
+	}
+}
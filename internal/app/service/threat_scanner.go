@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+// Flag actions decide what happens to links the threat scanner finds on a blocklist.
+const (
+	// FlagActionDisable stops redirecting until a reviewer clears the flag.
+	FlagActionDisable = "disable"
+	// FlagActionWarn shows visitors a warning they have to click through.
+	FlagActionWarn = "warn"
+)
+
+const threatScanBatch = 500
+
+// ThreatFeed matches URLs against malicious-URL blocklists.
+type ThreatFeed interface {
+	// Match returns why raw is listed, or "" when it is not.
+	Match(raw string) string
+}
+
+// threatMatch returns why one of destinations is listed in feed, or "".
+func threatMatch(feed ThreatFeed, destinations []destinationField) (destinationField, string) {
+	for _, d := range destinations {
+		if reason := feed.Match(d.url); reason != "" {
+			return d, reason
+		}
+	}
+	return destinationField{}, ""
+}
+
+// ThreatScanner periodically checks the destinations of every stored link against
+// the threat feed and flags listed links. Flags are only lifted by a reviewer.
+type ThreatScanner struct {
+	logger   *zap.Logger
+	repo     repository.LinkRepository
+	feed     ThreatFeed
+	action   string
+	interval time.Duration
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// NewThreatScanner creates a scanner running every interval and applying action,
+// FlagActionDisable or FlagActionWarn, to listed links.
+func NewThreatScanner(logger *zap.Logger, repo repository.LinkRepository, feed ThreatFeed, action string, interval time.Duration) *ThreatScanner {
+	if action != FlagActionDisable {
+		action = FlagActionWarn
+	}
+	return &ThreatScanner{
+		logger:   logger,
+		repo:     repo,
+		feed:     feed,
+		action:   action,
+		interval: interval,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start scans once right away and then periodically.
+func (s *ThreatScanner) Start() {
+	go s.run()
+}
+
+// Stop stops the scanner, waiting for a running scan to finish.
+func (s *ThreatScanner) Stop() {
+	close(s.stopChan)
+	<-s.done
+}
+
+func (s *ThreatScanner) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.scan()
+	for {
+		select {
+		case <-ticker.C:
+			s.scan()
+		case <-s.stopChan:
+			s.logger.Info("threat scanner stopped")
+			return
+		}
+	}
+}
+
+func (s *ThreatScanner) scan() {
+	flagged, err := s.Scan(context.Background())
+	if err != nil {
+		s.logger.Error("threat scan failed", zap.Error(err), zap.Int("flagged", flagged))
+		return
+	}
+	if flagged > 0 {
+		s.logger.Warn("threat scan flagged links", zap.Int("count", flagged))
+	}
+}
+
+// Scan checks every live link once and returns how many links it flagged.
+func (s *ThreatScanner) Scan(ctx context.Context) (int, error) {
+	flagged := 0
+	after := ""
+	for {
+		links, err := s.repo.ListAfter(ctx, after, threatScanBatch)
+		if err != nil {
+			return flagged, err
+		}
+		for i := range links {
+			link := &links[i]
+			if !s.shouldScan(link) {
+				continue
+			}
+			d, reason := threatMatch(s.feed, linkDestinations(link))
+			if reason == "" {
+				continue
+			}
+			if err := s.repo.Flag(ctx, link.Code, d.field+": "+reason, s.action); err != nil {
+				return flagged, err
+			}
+			flagged++
+			s.logger.Warn("flagged link with listed destination",
+				zap.String("code", link.Code),
+				zap.String("field", d.field),
+				zap.String("reason", reason),
+				zap.String("action", s.action))
+		}
+		if len(links) < threatScanBatch {
+			return flagged, nil
+		}
+		after = links[len(links)-1].Code
+	}
+}
+
+// shouldScan skips flagged links and links a reviewer cleared that have not changed since.
+func (s *ThreatScanner) shouldScan(link *model.Link) bool {
+	if link.Flagged() {
+		return false
+	}
+	return link.FlagReviewedAt == nil || link.UpdatedAt.After(*link.FlagReviewedAt)
+}
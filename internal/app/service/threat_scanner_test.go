@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"go.uber.org/zap"
+)
+
+// stubThreatFeed lists URLs containing any of its substrings.
+type stubThreatFeed []string
+
+func (f stubThreatFeed) Match(raw string) string {
+	for _, listed := range f {
+		if strings.Contains(raw, listed) {
+			return "listed in stub"
+		}
+	}
+	return ""
+}
+
+func TestThreatScanner_Scan(t *testing.T) {
+	reviewed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	flaggedAt := reviewed.Add(-time.Hour)
+	links := []model.Link{
+		{Code: "clean", URL: "https://example.com"},
+		{Code: "evil", URL: "https://example.com", Variants: model.LinkVariants{{ID: "b", Destination: "https://evil.test/login", Weight: 1}}},
+		{Code: "known", URL: "https://evil.test", FlaggedAt: &flaggedAt},
+		{Code: "cleared", URL: "https://evil.test", FlagReviewedAt: &reviewed, UpdatedAt: reviewed.Add(-time.Minute)},
+		{Code: "edited", URL: "https://evil.test", FlagReviewedAt: &reviewed, UpdatedAt: reviewed.Add(time.Minute)},
+	}
+
+	flags := map[string]string{}
+	repo := &mockLinkRepository{
+		afterFn: func(ctx context.Context, afterCode string, limit int) ([]model.Link, error) {
+			if afterCode != "" {
+				return nil, nil
+			}
+			return links, nil
+		},
+		flagFn: func(ctx context.Context, code, reason, action string) error {
+			if action != FlagActionDisable {
+				t.Errorf("expected action %q, got %q", FlagActionDisable, action)
+			}
+			flags[code] = reason
+			return nil
+		},
+	}
+
+	scanner := NewThreatScanner(zap.NewNop(), repo, stubThreatFeed{"evil.test"}, FlagActionDisable, time.Hour)
+	flagged, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if flagged != 2 || len(flags) != 2 {
+		t.Fatalf("expected evil and edited to be flagged, got %v", flags)
+	}
+	if flags["evil"] != "variants[0].destination: listed in stub" {
+		t.Fatalf("unexpected reason %q", flags["evil"])
+	}
+	if _, ok := flags["edited"]; !ok {
+		t.Fatalf("expected a link changed after review to be flagged again, got %v", flags)
+	}
+}
+
+func TestLinkService_CreateLink_RejectsListedDestinations(t *testing.T) {
+	svc := NewLinkServiceWithDeps(LinkServiceDeps{
+		Repo:       &mockLinkRepository{},
+		ThreatFeed: stubThreatFeed{"evil.test"},
+	})
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{
+		Code:       "promo",
+		URL:        "https://example.com",
+		GeoTargets: model.GeoTargets{"DE": "https://evil.test/de"},
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "geo_targets.DE" {
+		t.Fatalf("expected a geo_targets.DE validation error, got %v", err)
+	}
+
+	if _, err := svc.CreateLink(context.Background(), CreateLinkInput{Code: "safe", URL: "https://example.com"}); err != nil {
+		t.Fatalf("expected a clean destination to be accepted, got %v", err)
+	}
+}
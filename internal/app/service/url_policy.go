@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+const defaultDNSTimeout = 2 * time.Second
+
+var defaultAllowedSchemes = []string{"http", "https"}
+
+// unsafeSchemes execute or embed content in the visitor's browser and are never allowed.
+var unsafeSchemes = []string{"javascript", "data", "vbscript", "file", "blob"}
+
+// reservedPrefixes are special-purpose ranges not covered by the netip.Addr predicates.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, includes broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, may embed any IPv4 address
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// Resolver looks up the addresses of a host name. *net.Resolver implements it.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// URLPolicy decides whether a URL may be used as a link destination, so short links
+// cannot be turned into script injections, loops or doorways into private networks.
+type URLPolicy struct {
+	schemes      []string
+	blockedHosts []string
+	shortDomains []string
+	allowPrivate bool
+	resolver     Resolver
+	dnsTimeout   time.Duration
+}
+
+// NewURLPolicy builds a policy from configuration. Host names are only resolved
+// when resolver is not nil.
+func NewURLPolicy(cfg config.URLPolicyConfig, resolver Resolver) (*URLPolicy, error) {
+	p := &URLPolicy{
+		schemes:      normalizeList(cfg.AllowedSchemes),
+		blockedHosts: normalizeHosts(cfg.BlockedHosts),
+		shortDomains: normalizeHosts(cfg.ShortDomains),
+		allowPrivate: cfg.AllowPrivateNetworks,
+		resolver:     resolver,
+		dnsTimeout:   defaultDNSTimeout,
+	}
+	if len(p.schemes) == 0 {
+		p.schemes = defaultAllowedSchemes
+	}
+	for _, scheme := range p.schemes {
+		if slices.Contains(unsafeSchemes, scheme) {
+			return nil, fmt.Errorf("url policy: scheme %q cannot be allowed", scheme)
+		}
+	}
+	if cfg.DNSTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.DNSTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("url policy: invalid dns_timeout %q", cfg.DNSTimeout)
+		}
+		p.dnsTimeout = timeout
+	}
+	return p, nil
+}
+
+// Validate returns a FieldError for field when raw may not be used as a destination.
+// Resolution failures are not held against a host; only addresses it does resolve to are checked.
+func (p *URLPolicy) Validate(ctx context.Context, field, raw string) *FieldError {
+	invalid := func(format string, args ...interface{}) *FieldError {
+		return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+	}
+
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" {
+		return invalid("must be an absolute URL")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.Contains(p.schemes, scheme) {
+		return invalid("scheme %q is not allowed; use one of: %s", scheme, strings.Join(p.schemes, ", "))
+	}
+	if u.Opaque != "" || (scheme != "http" && scheme != "https" && u.Host == "") {
+		// Non-hierarchical URLs such as mailto: have no host to check.
+		return nil
+	}
+	if u.User != nil {
+		return invalid("must not contain credentials")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return invalid("must include a host")
+	}
+	if matchesHost(p.shortDomains, host) {
+		return invalid("must not point back to this link shortener")
+	}
+	if matchesHost(p.blockedHosts, host) {
+		return invalid("host %q is blocked", host)
+	}
+	if p.allowPrivate {
+		return nil
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return invalid("must not point to a private or reserved network address")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return invalid("must not point to a private or reserved network address")
+		}
+		return nil
+	}
+	if numericHost(host) {
+		// Browsers read hosts like 2130706433 or 0x7f.1 as IPv4 addresses.
+		return invalid("must not use a non-standard IP address notation")
+	}
+
+	if p.resolver == nil {
+		return nil
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, p.dnsTimeout)
+	defer cancel()
+	addrs, err := p.resolver.LookupNetIP(lookupCtx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return invalid("host %q resolves to a private or reserved network address", host)
+		}
+	}
+	return nil
+}
+
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// numericHost reports whether the last label of host is a number, which makes
+// browsers parse the whole host as an IPv4 address.
+func numericHost(host string) bool {
+	labels := strings.Split(host, ".")
+	last := labels[len(labels)-1]
+	if last == "" {
+		return false
+	}
+	if strings.HasPrefix(last, "0x") {
+		last = last[2:]
+		return strings.Trim(last, "0123456789abcdef") == ""
+	}
+	return strings.Trim(last, "0123456789") == ""
+}
+
+// matchesHost reports whether host is one of hosts or a subdomain of one.
+func matchesHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeList(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func normalizeHosts(hosts []string) []string {
+	out := normalizeList(hosts)
+	for i, h := range out {
+		if u, err := url.Parse(h); err == nil && u.Host != "" {
+			// Accept "https://sho.rt" as well as "sho.rt".
+			h = u.Hostname()
+		}
+		out[i] = strings.TrimSuffix(strings.TrimPrefix(h, "*."), ".")
+	}
+	return out
+}
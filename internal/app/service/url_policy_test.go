@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/app/model"
+)
+
+type stubResolver map[string][]netip.Addr
+
+func (r stubResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestURLPolicy_Validate(t *testing.T) {
+	resolver := stubResolver{
+		"intranet.example.com": {netip.MustParseAddr("10.0.0.8")},
+		"www.example.com":      {netip.MustParseAddr("93.184.216.34")},
+	}
+	policy, err := NewURLPolicy(config.URLPolicyConfig{
+		BlockedHosts: []string{"evil.example"},
+		ShortDomains: []string{"https://sho.rt"},
+	}, resolver)
+	if err != nil {
+		t.Fatalf("NewURLPolicy error: %v", err)
+	}
+
+	cases := map[string]bool{
+		"https://www.example.com/landing":   true,
+		"https://unresolvable.example.org/": true,
+		"javascript:alert(1)":               false,
+		"data:text/html,<script>":           false,
+		"ftp://files.example.com/":          false,
+		"https://user:pw@www.example.com/":  false,
+		"http://localhost:8080/":            false,
+		"http://app.localhost/":             false,
+		"http://127.0.0.1/":                 false,
+		"http://[::1]/":                     false,
+		"http://[::ffff:192.168.1.1]/":      false,
+		"http://169.254.169.254/latest":     false,
+		"http://2130706433/":                false,
+		"http://0x7f.1/":                    false,
+		"https://intranet.example.com/":     false,
+		"https://cdn.evil.example/x":        false,
+		"https://sho.rt/abc":                false,
+		"https://SHO.RT./abc":               false,
+	}
+	for raw, allowed := range cases {
+		fieldErr := policy.Validate(context.Background(), "url", raw)
+		if got := fieldErr == nil; got != allowed {
+			t.Errorf("%s: expected allowed=%v, got %+v", raw, allowed, fieldErr)
+		}
+	}
+
+	if _, err := NewURLPolicy(config.URLPolicyConfig{AllowedSchemes: []string{"https", "javascript"}}, nil); err == nil {
+		t.Fatal("expected javascript to be refused as an allowed scheme")
+	}
+}
+
+func TestLinkService_CreateLink_RejectsUnsafeDestinations(t *testing.T) {
+	repo := &mockLinkRepository{
+		createFn: func(ctx context.Context, link *model.Link) error {
+			t.Fatal("unsafe link must not be stored")
+			return nil
+		},
+	}
+	svc := NewLinkService(repo)
+
+	_, err := svc.CreateLink(context.Background(), CreateLinkInput{
+		Code: "unsafe",
+		URL:  "javascript:alert(document.cookie)",
+		Variants: model.LinkVariants{
+			{ID: "a", Destination: "https://www.example.com/a", Weight: 1},
+			{ID: "b", Destination: "http://10.1.2.3/admin", Weight: 1},
+		},
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 {
+		t.Fatalf("expected url and variant validation errors, got %v", err)
+	}
+	if validationErr.Fields[0].Field != "url" || validationErr.Fields[1].Field != "variants[1].destination" {
+		t.Fatalf("unexpected fields %+v", validationErr.Fields)
+	}
+}
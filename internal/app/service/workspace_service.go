@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+)
+
+// ErrDefaultWorkspace is returned when trying to delete the default workspace.
+var ErrDefaultWorkspace = errors.New("the default workspace cannot be deleted")
+
+// WorkspaceService manages workspaces and their membership.
+type WorkspaceService interface {
+	CreateWorkspace(ctx context.Context, name, ownerID string) (*model.Workspace, error)
+	GetWorkspace(ctx context.Context, id string) (*model.Workspace, error)
+	ListWorkspaces(ctx context.Context, limit, offset int) ([]model.Workspace, error)
+	RenameWorkspace(ctx context.Context, id, name string) (*model.Workspace, error)
+	DeleteWorkspace(ctx context.Context, id string) error
+	SetMember(ctx context.Context, workspaceID, memberID, role string) (*model.WorkspaceMember, error)
+	RemoveMember(ctx context.Context, workspaceID, memberID string) error
+	ListMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error)
+	CanAccess(ctx context.Context, key *model.APIKey, workspaceID string) (bool, error)
+	EnsureDefaultWorkspace(ctx context.Context) error
+}
+
+type workspaceService struct {
+	repo repository.WorkspaceRepository
+}
+
+// NewWorkspaceService returns a WorkspaceService backed by the given repository.
+func NewWorkspaceService(repo repository.WorkspaceRepository) WorkspaceService {
+	return &workspaceService{repo: repo}
+}
+
+func (s *workspaceService) CreateWorkspace(ctx context.Context, name, ownerID string) (*model.Workspace, error) {
+	workspace := &model.Workspace{
+		ID:   uuid.New().String(),
+		Name: strings.TrimSpace(name),
+	}
+
+	var owner *model.WorkspaceMember
+	if ownerID = strings.TrimSpace(ownerID); ownerID != "" {
+		owner = &model.WorkspaceMember{MemberID: ownerID, Role: model.WorkspaceRoleOwner}
+	}
+
+	if err := s.repo.Create(ctx, workspace, owner); err != nil {
+		return nil, fmt.Errorf("create workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+func (s *workspaceService) GetWorkspace(ctx context.Context, id string) (*model.Workspace, error) {
+	workspace, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+func (s *workspaceService) ListWorkspaces(ctx context.Context, limit, offset int) ([]model.Workspace, error) {
+	workspaces, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+func (s *workspaceService) RenameWorkspace(ctx context.Context, id, name string) (*model.Workspace, error) {
+	workspace, err := s.repo.Rename(ctx, id, strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("rename workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+func (s *workspaceService) DeleteWorkspace(ctx context.Context, id string) error {
+	if id == model.DefaultWorkspaceID {
+		return ErrDefaultWorkspace
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete workspace: %w", err)
+	}
+	return nil
+}
+
+func (s *workspaceService) SetMember(ctx context.Context, workspaceID, memberID, role string) (*model.WorkspaceMember, error) {
+	if role == "" {
+		role = model.WorkspaceRoleMember
+	}
+	if !model.ValidWorkspaceRole(role) {
+		return nil, &ValidationError{Fields: []FieldError{{
+			Field:   "role",
+			Message: fmt.Sprintf("must be one of: %s, %s", model.WorkspaceRoleOwner, model.WorkspaceRoleMember),
+		}}}
+	}
+
+	member := &model.WorkspaceMember{WorkspaceID: workspaceID, MemberID: memberID, Role: role}
+	if err := s.repo.SaveMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("save workspace member: %w", err)
+	}
+	return member, nil
+}
+
+func (s *workspaceService) RemoveMember(ctx context.Context, workspaceID, memberID string) error {
+	if err := s.repo.RemoveMember(ctx, workspaceID, memberID); err != nil {
+		return fmt.Errorf("remove workspace member: %w", err)
+	}
+	return nil
+}
+
+func (s *workspaceService) ListMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	members, err := s.repo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list workspace members: %w", err)
+	}
+	return members, nil
+}
+
+// CanAccess reports whether key may act in workspaceID: its own workspace always,
+// any workspace for admin keys, and otherwise workspaces its owner is a member of.
+func (s *workspaceService) CanAccess(ctx context.Context, key *model.APIKey, workspaceID string) (bool, error) {
+	if workspaceID == key.WorkspaceID {
+		return true, nil
+	}
+	if key.HasScope(model.ScopeAdmin) {
+		if _, err := s.repo.Get(ctx, workspaceID); err != nil {
+			if errors.Is(err, repository.ErrWorkspaceNotFound) {
+				return false, nil
+			}
+			return false, fmt.Errorf("get workspace: %w", err)
+		}
+		return true, nil
+	}
+	if key.OwnerID == "" {
+		return false, nil
+	}
+
+	if _, err := s.repo.GetMember(ctx, workspaceID, key.OwnerID); err != nil {
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get workspace member: %w", err)
+	}
+	return true, nil
+}
+
+// EnsureDefaultWorkspace creates the workspace that owns pre-existing links.
+func (s *workspaceService) EnsureDefaultWorkspace(ctx context.Context) error {
+	if err := s.repo.Ensure(ctx, &model.Workspace{ID: model.DefaultWorkspaceID, Name: "Default"}); err != nil {
+		return fmt.Errorf("ensure default workspace: %w", err)
+	}
+	return nil
+}
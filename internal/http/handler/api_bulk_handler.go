@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"go.uber.org/zap"
+)
+
+const maxBulkRows = 1000
+
+// BulkRowResult reports the outcome of a single row of a bulk create request.
+type BulkRowResult struct {
+	Row    int                  `json:"row"`
+	Code   string               `json:"code,omitempty"`
+	URL    string               `json:"url,omitempty"`
+	Error  string               `json:"error,omitempty"`
+	Fields []service.FieldError `json:"fields,omitempty"`
+}
+
+// BulkCreateLinks handles POST /api/links/bulk
+// Accepts either a JSON array of CreateLinkRequest or a multipart CSV upload in the
+// "file" field. Pass all_or_nothing=true to create every row in a single transaction.
+func (h *APIHandler) BulkCreateLinks(c *fiber.Ctx) error {
+	allOrNothing := c.QueryBool("all_or_nothing")
+
+	var (
+		requests []CreateLinkRequest
+		rowErrs  []error
+		err      error
+	)
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		if v := c.FormValue("all_or_nothing"); v != "" {
+			allOrNothing, _ = strconv.ParseBool(v)
+		}
+		requests, rowErrs, err = parseBulkCSV(c)
+	} else {
+		if parseErr := c.BodyParser(&requests); parseErr != nil {
+			err = errors.New("invalid request body")
+		}
+		rowErrs = make([]error, len(requests))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if len(requests) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "at least one link is required",
+		})
+	}
+	if len(requests) > maxBulkRows {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("at most %d links can be created per request", maxBulkRows),
+		})
+	}
+
+	results := make([]BulkRowResult, len(requests))
+	inputs := make([]service.CreateLinkInput, 0, len(requests))
+	rowIndex := make([]int, 0, len(requests))
+	invalid := false
+	for i, req := range requests {
+		results[i] = BulkRowResult{Row: i + 1, Code: req.Code, URL: req.URL}
+		if rowErrs[i] == nil {
+			if msg := validateCreateLinkRequest(&req); msg != "" {
+				rowErrs[i] = errors.New(msg)
+			}
+		}
+		if rowErrs[i] != nil {
+			results[i].Error = rowErrs[i].Error()
+			invalid = true
+			continue
+		}
+		inputs = append(inputs, req.toInput())
+		rowIndex = append(rowIndex, i)
+	}
+
+	if invalid && allOrNothing {
+		for i := range results {
+			if results[i].Error == "" {
+				results[i].Error = bulkErrorMessage(service.ErrBulkAborted)
+			}
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(bulkResponse(results, allOrNothing))
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	created, err := h.linkService.BulkCreateLinks(ctx, inputs, allOrNothing)
+	if err != nil && !errors.Is(err, service.ErrBulkAborted) {
+		h.logger.Error("failed to bulk create links", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create links",
+		})
+	}
+
+	failed := invalid
+	for j, res := range created {
+		row := &results[rowIndex[j]]
+		if res.Err != nil {
+			failed = true
+			row.Error = bulkErrorMessage(res.Err)
+			var validationErr *service.ValidationError
+			if errors.As(res.Err, &validationErr) {
+				row.Fields = validationErr.Fields
+			}
+			continue
+		}
+		row.Code = res.Link.Code
+	}
+
+	status := fiber.StatusCreated
+	switch {
+	case errors.Is(err, service.ErrBulkAborted):
+		status = fiber.StatusUnprocessableEntity
+	case failed:
+		status = fiber.StatusMultiStatus
+	}
+	return c.Status(status).JSON(bulkResponse(results, allOrNothing))
+}
+
+func bulkResponse(results []BulkRowResult, allOrNothing bool) fiber.Map {
+	createdCount := 0
+	for _, r := range results {
+		if r.Error == "" {
+			createdCount++
+		}
+	}
+	return fiber.Map{
+		"results":        results,
+		"created":        createdCount,
+		"failed":         len(results) - createdCount,
+		"all_or_nothing": allOrNothing,
+	}
+}
+
+func bulkErrorMessage(err error) string {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return "validation failed"
+	case errors.Is(err, repository.ErrDuplicateCode):
+		return "code already exists"
+	case errors.Is(err, repository.ErrCodeQuarantined):
+		return "code belonged to a deleted link and is not available yet"
+	case errors.Is(err, service.ErrBulkAborted):
+		return "not created: another row failed"
+	case errors.Is(err, service.ErrCodeGenerationExhausted):
+		return "could not generate a unique code"
+	default:
+		return "failed to create link"
+	}
+}
+
+// parseBulkCSV reads the uploaded CSV file. The first row must be a header naming
+// the columns: url (required), code, mode, timer_seconds, disabled, starts_at and
+// expires_at (RFC 3339), max_clicks, password, query_policy, forward_path.
+func parseBulkCSV(c *fiber.Ctx) ([]CreateLinkRequest, []error, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, nil, errors.New("multipart field \"file\" is required")
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		return nil, nil, errors.New("failed to open uploaded file")
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("csv header row is required")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, nil, errors.New("csv header must include a url column")
+	}
+
+	var (
+		requests []CreateLinkRequest
+		rowErrs  []error
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid csv: %v", err)
+		}
+		if len(requests) >= maxBulkRows {
+			// Surface the limit through the regular size check.
+			requests = append(requests, CreateLinkRequest{})
+			rowErrs = append(rowErrs, nil)
+			break
+		}
+
+		req, rowErr := csvRecordToRequest(record, columns)
+		requests = append(requests, req)
+		rowErrs = append(rowErrs, rowErr)
+	}
+
+	return requests, rowErrs, nil
+}
+
+func csvRecordToRequest(record []string, columns map[string]int) (CreateLinkRequest, error) {
+	field := func(name string) string {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	req := CreateLinkRequest{
+		Code:        field("code"),
+		URL:         field("url"),
+		Mode:        field("mode"),
+		Password:    field("password"),
+		QueryPolicy: field("query_policy"),
+	}
+
+	if v := field("timer_seconds"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return req, errors.New("timer_seconds must be an integer")
+		}
+		req.TimerSeconds = n
+	}
+	if v := field("disabled"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return req, errors.New("disabled must be true or false")
+		}
+		req.Disabled = b
+	}
+	if v := field("forward_path"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return req, errors.New("forward_path must be true or false")
+		}
+		req.ForwardPath = b
+	}
+	if v := field("starts_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return req, errors.New("starts_at must be an RFC 3339 timestamp")
+		}
+		req.StartsAt = &t
+	}
+	if v := field("expires_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return req, errors.New("expires_at must be an RFC 3339 timestamp")
+		}
+		req.ExpiresAt = &t
+	}
+	if v := field("max_clicks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return req, errors.New("max_clicks must be an integer")
+		}
+		req.MaxClicks = &n
+	}
+
+	return req, nil
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+// LinkFlagResponse explains why the threat scanner flagged a link.
+type LinkFlagResponse struct {
+	Reason    string    `json:"reason"` // the destination field and the blocklist entry it matched
+	Action    string    `json:"action"` // disable or warn
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+// ListFlaggedLinks handles GET /api/flagged/links
+// Links of every workspace are listed, most recently flagged first.
+func (h *APIHandler) ListFlaggedLinks(c *fiber.Ctx) error {
+	limit, offset := parsePagination(c)
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	links, err := h.linkService.ListFlaggedLinks(ctx, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list flagged links", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list flagged links",
+		})
+	}
+
+	response := newLinkResponses(links)
+	return c.JSON(fiber.Map{
+		"links":  response,
+		"limit":  limit,
+		"offset": offset,
+		"count":  len(response),
+	})
+}
+
+// ClearLinkFlag handles DELETE /api/flagged/links/:code
+// Clearing a flag marks the link as reviewed; it is not flagged again until it changes.
+func (h *APIHandler) ClearLinkFlag(c *fiber.Ctx) error {
+	code := c.Params("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code is required",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.ClearFlag(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to clear link flag", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to clear link flag",
+		})
+	}
+
+	h.logger.Info("cleared link flag", zap.String("code", code))
+	return c.JSON(newLinkResponse(link))
+}
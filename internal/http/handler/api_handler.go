@@ -2,23 +2,46 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
 	"github.com/sifan077/PowerURL/internal/app/service"
+	"github.com/sifan077/PowerURL/internal/http/middleware"
+	httpUtil "github.com/sifan077/PowerURL/internal/http/util"
 	"go.uber.org/zap"
 )
 
 // APIDeps groups dependencies required by API handlers.
 type APIDeps struct {
-	Logger      *zap.Logger
-	LinkService service.LinkService
+	Logger           *zap.Logger
+	LinkService      service.LinkService
+	ClickService     service.ClickService
+	APIKeyService    service.APIKeyService
+	WorkspaceService service.WorkspaceService
+	// Idempotency stores responses to POST /api/links sent with an Idempotency-Key
+	// for IdempotencyWindow. Without a store the header is ignored.
+	Idempotency       repository.IdempotencyStore
+	IdempotencyWindow time.Duration
+	// Auth authenticates and scopes every /api request; routes additionally check scopes.
+	Auth []fiber.Handler
 }
 
 // APIHandler implements the management API endpoints.
 type APIHandler struct {
-	logger      *zap.Logger
-	linkService service.LinkService
+	logger            *zap.Logger
+	linkService       service.LinkService
+	clickService      service.ClickService
+	apiKeyService     service.APIKeyService
+	workspaceService  service.WorkspaceService
+	idempotency       repository.IdempotencyStore
+	idempotencyWindow time.Duration
+	auth              []fiber.Handler
 }
 
 // NewAPIHandler creates an API handler with the provided dependencies.
@@ -28,48 +51,277 @@ func NewAPIHandler(deps APIDeps) *APIHandler {
 		logger = zap.NewNop()
 	}
 	return &APIHandler{
-		logger:      logger,
-		linkService: deps.LinkService,
+		logger:            logger,
+		linkService:       deps.LinkService,
+		clickService:      deps.ClickService,
+		apiKeyService:     deps.APIKeyService,
+		workspaceService:  deps.WorkspaceService,
+		idempotency:       deps.Idempotency,
+		idempotencyWindow: deps.IdempotencyWindow,
+		auth:              deps.Auth,
 	}
 }
 
 // Register wires API routes onto the provided router.
 func (h *APIHandler) Register(router fiber.Router) {
 	api := router.Group("/api")
+	for _, handler := range h.auth {
+		api.Use(handler)
+	}
 	{
+		read := middleware.RequireScope(model.ScopeLinksRead)
+		write := middleware.RequireScope(model.ScopeLinksWrite)
+
 		links := api.Group("/links")
 		{
-			links.Post("/", h.CreateLink)
-			links.Get("/", h.ListLinks)
-			links.Get("/:code", h.GetLink)
-			links.Patch("/:code", h.UpdateLink)
+			links.Post("/", write, h.CreateLink)
+			links.Post("/bulk", write, h.BulkCreateLinks)
+			links.Get("/", read, h.ListLinks)
+			links.Get("/:code", read, h.GetLink)
+			links.Patch("/:code", write, h.UpdateLink)
+			links.Delete("/:code", write, h.DeleteLink)
+			links.Post("/:code/restore", write, h.RestoreLink)
+			links.Get("/:code/clicks", read, h.ListClicks)
+			links.Post("/:code/clicks/reset", write, h.ResetClickCount)
+			links.Get("/:code/revisions", read, h.ListRevisions)
+			links.Post("/:code/revisions/:id/revert", write, h.RevertRevision)
+			links.Get("/:code/rules", read, h.ListRules)
+			links.Put("/:code/rules", write, h.ReplaceRules)
+			links.Post("/:code/rules", write, h.CreateRule)
+			links.Put("/:code/rules/:id", write, h.UpdateRule)
+			links.Delete("/:code/rules/:id", write, h.DeleteRule)
+			links.Get("/:code/variants/stats", read, h.VariantStats)
+		}
+
+		trash := api.Group("/trash")
+		{
+			trash.Get("/links", read, h.ListDeletedLinks)
+		}
+
+		// Trust & safety review of links flagged by the threat scanner, across workspaces.
+		flagged := api.Group("/flagged", middleware.RequireScope(model.ScopeAdmin))
+		{
+			flagged.Get("/links", h.ListFlaggedLinks)
+			flagged.Delete("/links/:code", h.ClearLinkFlag)
+		}
+
+		keys := api.Group("/keys", middleware.RequireScope(model.ScopeAdmin))
+		{
+			keys.Post("/", h.CreateAPIKey)
+			keys.Get("/", h.ListAPIKeys)
+			keys.Delete("/:id", h.RevokeAPIKey)
+		}
+
+		workspaces := api.Group("/workspaces", middleware.RequireScope(model.ScopeAdmin))
+		{
+			workspaces.Post("/", h.CreateWorkspace)
+			workspaces.Get("/", h.ListWorkspaces)
+			workspaces.Get("/:id", h.GetWorkspace)
+			workspaces.Patch("/:id", h.UpdateWorkspace)
+			workspaces.Delete("/:id", h.DeleteWorkspace)
+			workspaces.Get("/:id/members", h.ListWorkspaceMembers)
+			workspaces.Put("/:id/members/:member", h.SetWorkspaceMember)
+			workspaces.Delete("/:id/members/:member", h.RemoveWorkspaceMember)
 		}
 	}
 }
 
 // CreateLinkRequest represents the request body for creating a link.
 type CreateLinkRequest struct {
-	Code         string     `json:"code,omitempty"`
-	URL          string     `json:"url" validate:"required,url"`
-	Mode         string     `json:"mode,omitempty" validate:"omitempty,oneof=direct click timer"`
-	TimerSeconds int        `json:"timer_seconds,omitempty" validate:"omitempty,min=0,max=300"`
-	Disabled     bool       `json:"disabled,omitempty"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Code         string             `json:"code,omitempty"`
+	URL          string             `json:"url" validate:"required,url"`
+	Mode         string             `json:"mode,omitempty" validate:"omitempty,oneof=direct click timer password"`
+	TimerSeconds int                `json:"timer_seconds,omitempty" validate:"omitempty,min=0,max=300"`
+	Disabled     bool               `json:"disabled,omitempty"`
+	StartsAt     *time.Time         `json:"starts_at,omitempty"`
+	ExpiresAt    *time.Time         `json:"expires_at,omitempty"`
+	MaxClicks    *int               `json:"max_clicks,omitempty" validate:"omitempty,min=0"`
+	Password     string             `json:"password,omitempty"` // required for mode password
+	Rules        model.LinkRules    `json:"rules,omitempty"`
+	GeoTargets   model.GeoTargets   `json:"geo_targets,omitempty"`  // country or region code -> destination
+	Variants     model.LinkVariants `json:"variants,omitempty"`     // weighted A/B destinations
+	QueryPolicy  string             `json:"query_policy,omitempty"` // ignore (default), merge or override
+	ForwardPath  bool               `json:"forward_path,omitempty"` // forward /code/<path> to the destination's <path>
+	Social       model.LinkSocial   `json:"social,omitempty"`       // title, description and image_url shown to social crawlers
+}
+
+func (r *CreateLinkRequest) toInput() service.CreateLinkInput {
+	return service.CreateLinkInput{
+		Code:         r.Code,
+		URL:          r.URL,
+		Mode:         r.Mode,
+		TimerSeconds: r.TimerSeconds,
+		Disabled:     r.Disabled,
+		StartsAt:     r.StartsAt,
+		ExpiresAt:    r.ExpiresAt,
+		MaxClicks:    r.MaxClicks,
+		Password:     r.Password,
+		Rules:        r.Rules,
+		GeoTargets:   r.GeoTargets,
+		Variants:     r.Variants,
+		QueryPolicy:  r.QueryPolicy,
+		ForwardPath:  r.ForwardPath,
+		Social:       r.Social,
+	}
+}
+
+// linkModes lists the redirect modes accepted by the API.
+var linkModes = []string{"direct", "click", "timer", service.ModePassword}
+
+const invalidModeMessage = "mode must be one of: direct, click, timer, password"
+
+// validateCreateLinkRequest returns a client-facing message when req is malformed.
+func validateCreateLinkRequest(req *CreateLinkRequest) string {
+	if req.URL == "" {
+		return "url is required"
+	}
+
+	if req.Mode != "" && !slices.Contains(linkModes, req.Mode) {
+		return invalidModeMessage
+	}
+
+	if req.TimerSeconds < 0 || req.TimerSeconds > 300 {
+		return "timer_seconds must be between 0 and 300"
+	}
+
+	if req.MaxClicks != nil && *req.MaxClicks < 0 {
+		return "max_clicks must not be negative"
+	}
+
+	return ""
 }
 
 // CreateLinkResponse represents the response for creating a link.
+// Password-mode links only report password_protected; their hash is never included.
 type CreateLinkResponse struct {
-	Code         string     `json:"code"`
-	URL          string     `json:"url"`
-	Mode         string     `json:"mode"`
-	TimerSeconds int        `json:"timer_seconds"`
-	Disabled     bool       `json:"disabled"`
-	ExpiresAt    *time.Time `json:"expires_at"`
-	CreatedAt    time.Time  `json:"created_at"`
+	Code              string               `json:"code"`
+	WorkspaceID       string               `json:"workspace_id"`
+	OwnerID           string               `json:"owner_id,omitempty"`
+	URL               string               `json:"url"`
+	Mode              string               `json:"mode"`
+	TimerSeconds      int                  `json:"timer_seconds"`
+	Disabled          bool                 `json:"disabled"`
+	PasswordProtected bool                 `json:"password_protected"`
+	StartsAt          *time.Time           `json:"starts_at"`
+	ExpiresAt         *time.Time           `json:"expires_at"`
+	MaxClicks         *int                 `json:"max_clicks"`
+	Rules             model.LinkRules      `json:"rules"`
+	GeoTargets        model.GeoTargets     `json:"geo_targets"`
+	Variants          model.LinkVariants   `json:"variants"`
+	QueryPolicy       string               `json:"query_policy"`
+	ForwardPath       bool                 `json:"forward_path"`
+	Social            model.LinkSocial     `json:"social"`
+	Flag              *LinkFlagResponse    `json:"flag,omitempty"`    // set while the threat scanner has the link flagged
+	Health            *LinkHealthResponse  `json:"health,omitempty"`  // set once the destination was probed
+	Preview           *LinkPreviewResponse `json:"preview,omitempty"` // set once the destination page was fetched
+	ClickCount        int64                `json:"click_count"`
+	Version           int                  `json:"version"`
+	CreatedAt         time.Time            `json:"created_at"`
+	DeletedAt         *time.Time           `json:"deleted_at,omitempty"`
+}
+
+func newLinkResponse(link *model.Link) CreateLinkResponse {
+	resp := CreateLinkResponse{
+		Code:              link.Code,
+		WorkspaceID:       link.WorkspaceID,
+		OwnerID:           link.OwnerID,
+		URL:               link.URL,
+		Mode:              link.Mode,
+		TimerSeconds:      link.TimerSeconds,
+		Disabled:          link.Disabled,
+		PasswordProtected: link.PasswordHash != "",
+		StartsAt:          link.StartsAt,
+		ExpiresAt:         link.ExpiresAt,
+		MaxClicks:         link.MaxClicks,
+		Rules:             link.Rules,
+		GeoTargets:        link.GeoTargets,
+		Variants:          link.Variants,
+		QueryPolicy:       link.QueryPolicy,
+		ForwardPath:       link.ForwardPath,
+		Social:            link.Social,
+		ClickCount:        link.ClickCount,
+		Version:           link.Version,
+		CreatedAt:         link.CreatedAt,
+	}
+	if resp.Rules == nil {
+		resp.Rules = model.LinkRules{}
+	}
+	if resp.GeoTargets == nil {
+		resp.GeoTargets = model.GeoTargets{}
+	}
+	if resp.Variants == nil {
+		resp.Variants = model.LinkVariants{}
+	}
+	if link.Flagged() {
+		resp.Flag = &LinkFlagResponse{
+			Reason:    link.FlagReason,
+			Action:    link.FlagAction,
+			FlaggedAt: *link.FlaggedAt,
+		}
+	}
+	if link.Health.CheckedAt != nil {
+		resp.Health = &LinkHealthResponse{
+			Status:    link.Health.Status,
+			LatencyMs: link.Health.LatencyMs,
+			Failures:  link.Health.Failures,
+			Broken:    link.Health.Broken,
+			Error:     link.Health.Error,
+			CheckedAt: *link.Health.CheckedAt,
+		}
+	}
+	if link.Preview.Describes(link.URL) {
+		resp.Preview = &LinkPreviewResponse{
+			Title:       link.Preview.Title,
+			Description: link.Preview.Description,
+			ImageURL:    link.Preview.ImageURL,
+			FaviconURL:  link.Preview.FaviconURL,
+			FetchedAt:   *link.Preview.FetchedAt,
+		}
+	}
+	if link.DeletedAt.Valid {
+		deletedAt := link.DeletedAt.Time
+		resp.DeletedAt = &deletedAt
+	}
+	return resp
+}
+
+// LinkHealthResponse reports the latest probe of a link's destination.
+type LinkHealthResponse struct {
+	Status    int       `json:"status"` // 0 when no response arrived
+	LatencyMs int       `json:"latency_ms"`
+	Failures  int       `json:"failures"` // consecutive failed probes
+	Broken    bool      `json:"broken"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// LinkPreviewResponse carries the metadata fetched from a link's destination page.
+type LinkPreviewResponse struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"` // og:image
+	FaviconURL  string    `json:"favicon_url,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+func newLinkResponses(links []model.Link) []CreateLinkResponse {
+	response := make([]CreateLinkResponse, len(links))
+	for i := range links {
+		response[i] = newLinkResponse(&links[i])
+	}
+	return response
 }
 
 // CreateLink handles POST /api/links
+// Retries carrying the same Idempotency-Key header replay the first response.
 func (h *APIHandler) CreateLink(c *fiber.Ctx) error {
+	if key := c.Get(HeaderIdempotencyKey); key != "" && h.idempotency != nil {
+		return h.idempotent(c, key, h.createLink)
+	}
+	return h.createLink(c)
+}
+
+func (h *APIHandler) createLink(c *fiber.Ctx) error {
 	var req CreateLinkRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -77,21 +329,9 @@ func (h *APIHandler) CreateLink(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.URL == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "url is required",
-		})
-	}
-
-	if req.Mode != "" && req.Mode != "direct" && req.Mode != "click" && req.Mode != "timer" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "mode must be one of: direct, click, timer",
-		})
-	}
-
-	if req.TimerSeconds < 0 || req.TimerSeconds > 300 {
+	if msg := validateCreateLinkRequest(&req); msg != "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "timer_seconds must be between 0 and 300",
+			"error": msg,
 		})
 	}
 
@@ -100,49 +340,44 @@ func (h *APIHandler) CreateLink(c *fiber.Ctx) error {
 		ctx = context.Background()
 	}
 
-	input := service.CreateLinkInput{
-		Code:         req.Code,
-		URL:          req.URL,
-		Mode:         req.Mode,
-		TimerSeconds: req.TimerSeconds,
-		Disabled:     req.Disabled,
-		ExpiresAt:    req.ExpiresAt,
-	}
-
-	link, err := h.linkService.CreateLink(ctx, input)
+	link, err := h.linkService.CreateLink(ctx, req.toInput())
 	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			return respondValidationError(c, validationErr)
+		}
+		if errors.Is(err, repository.ErrDuplicateCode) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "code already exists",
+			})
+		}
+		if errors.Is(err, repository.ErrCodeQuarantined) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "code belonged to a deleted link and is not available yet",
+			})
+		}
 		h.logger.Error("failed to create link", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to create link",
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(CreateLinkResponse{
-		Code:         link.Code,
-		URL:          link.URL,
-		Mode:         link.Mode,
-		TimerSeconds: link.TimerSeconds,
-		Disabled:     link.Disabled,
-		ExpiresAt:    link.ExpiresAt,
-		CreatedAt:    link.CreatedAt,
-	})
+	c.Set(fiber.HeaderETag, httpUtil.VersionETag(link.Version))
+	return c.Status(fiber.StatusCreated).JSON(newLinkResponse(link))
 }
 
 // ListLinks handles GET /api/links
+// Supported query parameters: limit, offset, cursor, mode (comma separated), disabled, broken,
+// state (active|expired|scheduled), created_after, created_before (RFC 3339), host, q and
+// sort (created_at, updated_at, starts_at, expires_at, code or url; prefix "-" for descending).
+// Listings sorted by created_at return a next_cursor; pass it back as cursor to
+// fetch the following page without the drift of offset paging.
 func (h *APIHandler) ListLinks(c *fiber.Ctx) error {
-	limit := 20
-	offset := 0
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsed := c.QueryInt("limit"); parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
-	}
-
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if parsed := c.QueryInt("offset"); parsed >= 0 {
-			offset = parsed
-		}
+	query, msg := parseLinkQuery(c)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
 	}
 
 	ctx := c.UserContext()
@@ -150,7 +385,7 @@ func (h *APIHandler) ListLinks(c *fiber.Ctx) error {
 		ctx = context.Background()
 	}
 
-	links, err := h.linkService.ListLinks(ctx, limit, offset)
+	page, err := h.linkService.QueryLinks(ctx, query)
 	if err != nil {
 		h.logger.Error("failed to list links", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -158,27 +393,110 @@ func (h *APIHandler) ListLinks(c *fiber.Ctx) error {
 		})
 	}
 
-	response := make([]CreateLinkResponse, len(links))
-	for i, link := range links {
-		response[i] = CreateLinkResponse{
-			Code:         link.Code,
-			URL:          link.URL,
-			Mode:         link.Mode,
-			TimerSeconds: link.TimerSeconds,
-			Disabled:     link.Disabled,
-			ExpiresAt:    link.ExpiresAt,
-			CreatedAt:    link.CreatedAt,
+	nextCursor := ""
+	if page.NextCursor != nil {
+		if nextCursor, err = httpUtil.EncodeCursor(page.NextCursor); err != nil {
+			h.logger.Error("failed to encode link cursor", zap.Error(err))
 		}
 	}
 
+	response := newLinkResponses(page.Links)
 	return c.JSON(fiber.Map{
-		"links":  response,
-		"limit":  limit,
-		"offset": offset,
-		"count":  len(response),
+		"links":       response,
+		"limit":       query.Limit,
+		"offset":      query.Offset,
+		"count":       len(response),
+		"total":       page.Total,
+		"sort":        query.Sort,
+		"next_cursor": nextCursor,
 	})
 }
 
+func parseLinkQuery(c *fiber.Ctx) (repository.LinkQuery, string) {
+	limit, offset := parsePagination(c)
+	query := repository.LinkQuery{
+		Limit:  limit,
+		Offset: offset,
+		Host:   strings.TrimSpace(c.Query("host")),
+		Search: strings.TrimSpace(c.Query("q")),
+		Sort:   c.Query("sort", repository.DefaultLinkSort),
+	}
+
+	if !repository.ValidLinkSort(query.Sort) {
+		return query, "sort must be one of: created_at, updated_at, starts_at, expires_at, code, url (prefix with - for descending)"
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		if !repository.KeysetSortable(query.Sort) {
+			return query, "cursor requires sorting by created_at"
+		}
+		var cursor repository.LinkCursor
+		if err := httpUtil.DecodeCursor(token, &cursor); err != nil || cursor.Code == "" {
+			return query, "invalid cursor"
+		}
+		if cursor.Desc != strings.HasPrefix(query.Sort, "-") {
+			return query, "cursor does not match the requested sort"
+		}
+		query.After = &cursor
+		query.Offset = 0
+	}
+
+	if modes := c.Query("mode"); modes != "" {
+		for _, mode := range strings.Split(modes, ",") {
+			mode = strings.TrimSpace(mode)
+			if !slices.Contains(linkModes, mode) {
+				return query, invalidModeMessage
+			}
+			query.Modes = append(query.Modes, mode)
+		}
+	}
+
+	if v := c.Query("disabled"); v != "" {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return query, "disabled must be true or false"
+		}
+		query.Disabled = &disabled
+	}
+
+	if v := c.Query("broken"); v != "" {
+		broken, err := strconv.ParseBool(v)
+		if err != nil {
+			return query, "broken must be true or false"
+		}
+		query.Broken = &broken
+	}
+
+	if state := c.Query("state"); state != "" {
+		switch state {
+		case repository.LinkStateActive, repository.LinkStateExpired, repository.LinkStateScheduled:
+		default:
+			return query, "state must be one of: active, expired, scheduled"
+		}
+		query.State = state
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+	} {
+		v := c.Query(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, param.name + " must be an RFC 3339 timestamp"
+		}
+		*param.dst = &t
+	}
+
+	return query, ""
+}
+
 // GetLink handles GET /api/links/:code
 func (h *APIHandler) GetLink(c *fiber.Ctx) error {
 	code := c.Params("code")
@@ -201,27 +519,30 @@ func (h *APIHandler) GetLink(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(CreateLinkResponse{
-		Code:         link.Code,
-		URL:          link.URL,
-		Mode:         link.Mode,
-		TimerSeconds: link.TimerSeconds,
-		Disabled:     link.Disabled,
-		ExpiresAt:    link.ExpiresAt,
-		CreatedAt:    link.CreatedAt,
-	})
+	c.Set(fiber.HeaderETag, httpUtil.VersionETag(link.Version))
+	return c.JSON(newLinkResponse(link))
 }
 
 // UpdateLinkRequest represents the request body for updating a link.
 type UpdateLinkRequest struct {
-	URL          *string    `json:"url,omitempty" validate:"omitempty,url"`
-	Mode         *string    `json:"mode,omitempty" validate:"omitempty,oneof=direct click timer"`
-	TimerSeconds *int       `json:"timer_seconds,omitempty" validate:"omitempty,min=0,max=300"`
-	Disabled     *bool      `json:"disabled,omitempty"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	URL          *string             `json:"url,omitempty" validate:"omitempty,url"`
+	Mode         *string             `json:"mode,omitempty" validate:"omitempty,oneof=direct click timer password"`
+	TimerSeconds *int                `json:"timer_seconds,omitempty" validate:"omitempty,min=0,max=300"`
+	Disabled     *bool               `json:"disabled,omitempty"`
+	StartsAt     *time.Time          `json:"starts_at,omitempty"`
+	ExpiresAt    *time.Time          `json:"expires_at,omitempty"`
+	MaxClicks    *int                `json:"max_clicks,omitempty" validate:"omitempty,min=0"` // 0 removes the cap
+	Password     *string             `json:"password,omitempty"`                              // replaces the password of a password-mode link
+	GeoTargets   *model.GeoTargets   `json:"geo_targets,omitempty"`                           // replaces all geo targets; {} removes them
+	Variants     *model.LinkVariants `json:"variants,omitempty"`                              // replaces all A/B variants; [] ends the test
+	QueryPolicy  *string             `json:"query_policy,omitempty"`                          // ignore, merge or override
+	ForwardPath  *bool               `json:"forward_path,omitempty"`
+	Social       *model.LinkSocial   `json:"social,omitempty"` // replaces all social overrides; {} removes them
 }
 
 // UpdateLink handles PATCH /api/links/:code
+// Send the ETag from GET as If-Match to reject the update with 412 when the link
+// changed in the meantime.
 func (h *APIHandler) UpdateLink(c *fiber.Ctx) error {
 	code := c.Params("code")
 	if code == "" {
@@ -237,9 +558,9 @@ func (h *APIHandler) UpdateLink(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Mode != nil && *req.Mode != "" && *req.Mode != "direct" && *req.Mode != "click" && *req.Mode != "timer" {
+	if req.Mode != nil && *req.Mode != "" && !slices.Contains(linkModes, *req.Mode) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "mode must be one of: direct, click, timer",
+			"error": invalidModeMessage,
 		})
 	}
 
@@ -249,6 +570,12 @@ func (h *APIHandler) UpdateLink(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.MaxClicks != nil && *req.MaxClicks < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "max_clicks must not be negative",
+		})
+	}
+
 	ctx := c.UserContext()
 	if ctx == nil {
 		ctx = context.Background()
@@ -259,24 +586,335 @@ func (h *APIHandler) UpdateLink(c *fiber.Ctx) error {
 		Mode:         req.Mode,
 		TimerSeconds: req.TimerSeconds,
 		Disabled:     req.Disabled,
+		StartsAt:     req.StartsAt,
 		ExpiresAt:    req.ExpiresAt,
+		MaxClicks:    req.MaxClicks,
+		Password:     req.Password,
+		GeoTargets:   req.GeoTargets,
+		Variants:     req.Variants,
+		QueryPolicy:  req.QueryPolicy,
+		ForwardPath:  req.ForwardPath,
+		Social:       req.Social,
+		IfVersions:   httpUtil.ParseIfMatch(c.Get(fiber.HeaderIfMatch)),
 	}
 
 	link, err := h.linkService.UpdateLink(ctx, code, input)
 	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			return respondValidationError(c, validationErr)
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": "link was modified since it was read",
+			})
+		}
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
 		h.logger.Error("failed to update link", zap.Error(err), zap.String("code", code))
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "link not found",
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update link",
 		})
 	}
 
-	return c.JSON(CreateLinkResponse{
-		Code:         link.Code,
-		URL:          link.URL,
-		Mode:         link.Mode,
-		TimerSeconds: link.TimerSeconds,
-		Disabled:     link.Disabled,
-		ExpiresAt:    link.ExpiresAt,
-		CreatedAt:    link.CreatedAt,
+	c.Set(fiber.HeaderETag, httpUtil.VersionETag(link.Version))
+	return c.JSON(newLinkResponse(link))
+}
+
+// ListClicks handles GET /api/links/:code/clicks
+// Clicks are listed newest first; pass next_cursor back as cursor for the next page.
+// Clicks classified as bots are left out unless include_bots=true.
+func (h *APIHandler) ListClicks(c *fiber.Ctx) error {
+	code := c.Params("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code is required",
+		})
+	}
+
+	limit, offset := parsePagination(c)
+	query := repository.ClickQuery{
+		LinkCode:    code,
+		Status:      c.Query("status"),
+		IncludeBots: c.QueryBool("include_bots"),
+		Limit:       limit,
+		Offset:      offset,
+	}
+	if token := c.Query("cursor"); token != "" {
+		var cursor repository.ClickCursor
+		if err := httpUtil.DecodeCursor(token, &cursor); err != nil || cursor.ID == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid cursor",
+			})
+		}
+		query.After = &cursor
+		query.Offset = 0
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if _, err := h.linkService.GetLink(ctx, code); err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to get link", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list clicks",
+		})
+	}
+
+	page, err := h.clickService.ListClicks(ctx, query)
+	if err != nil {
+		h.logger.Error("failed to list clicks", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list clicks",
+		})
+	}
+
+	nextCursor := ""
+	if page.NextCursor != nil {
+		if nextCursor, err = httpUtil.EncodeCursor(page.NextCursor); err != nil {
+			h.logger.Error("failed to encode click cursor", zap.Error(err))
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"clicks":      page.Events,
+		"limit":       query.Limit,
+		"offset":      query.Offset,
+		"count":       len(page.Events),
+		"next_cursor": nextCursor,
+	})
+}
+
+// ResetClickCount handles POST /api/links/:code/clicks/reset
+// Zeroing the counter re-enables a link that reached its max_clicks.
+func (h *APIHandler) ResetClickCount(c *fiber.Ctx) error {
+	code := c.Params("code")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.ResetClickCount(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to reset click count", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to reset click count",
+		})
+	}
+
+	return c.JSON(newLinkResponse(link))
+}
+
+// ListRevisions handles GET /api/links/:code/revisions
+func (h *APIHandler) ListRevisions(c *fiber.Ctx) error {
+	code := c.Params("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code is required",
+		})
+	}
+
+	limit, offset := parsePagination(c)
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	revisions, err := h.linkService.ListRevisions(ctx, code, limit, offset)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to list revisions", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list revisions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"revisions": revisions,
+		"limit":     limit,
+		"offset":    offset,
+		"count":     len(revisions),
 	})
-}
\ No newline at end of file
+}
+
+// RevertRevision handles POST /api/links/:code/revisions/:id/revert
+// Like updates it honours If-Match, answering 412 when the link changed meanwhile.
+func (h *APIHandler) RevertRevision(c *fiber.Ctx) error {
+	code := c.Params("code")
+	revisionID, err := strconv.ParseUint(c.Params("id"), 10, 0)
+	if code == "" || err != nil || revisionID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code and a numeric revision id are required",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.RevertLink(ctx, code, uint(revisionID), httpUtil.ParseIfMatch(c.Get(fiber.HeaderIfMatch)))
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			return respondValidationError(c, validationErr)
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": "link was modified since it was read",
+			})
+		}
+		if errors.Is(err, repository.ErrLinkNotFound) || errors.Is(err, repository.ErrRevisionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "revision not found",
+			})
+		}
+		h.logger.Error("failed to revert link", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to revert link",
+		})
+	}
+
+	c.Set(fiber.HeaderETag, httpUtil.VersionETag(link.Version))
+	return c.JSON(newLinkResponse(link))
+}
+
+// DeleteLink handles DELETE /api/links/:code
+// The link is moved to the trash unless ?purge=true is given, in which case it is
+// removed permanently and its code is quarantined.
+func (h *APIHandler) DeleteLink(c *fiber.Ctx) error {
+	code := c.Params("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code is required",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var err error
+	if c.QueryBool("purge") {
+		err = h.linkService.PurgeLink(ctx, code)
+	} else {
+		err = h.linkService.DeleteLink(ctx, code)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to delete link", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete link",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RestoreLink handles POST /api/links/:code/restore
+func (h *APIHandler) RestoreLink(c *fiber.Ctx) error {
+	code := c.Params("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code is required",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.RestoreLink(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "deleted link not found",
+			})
+		}
+		h.logger.Error("failed to restore link", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to restore link",
+		})
+	}
+
+	return c.JSON(newLinkResponse(link))
+}
+
+// ListDeletedLinks handles GET /api/trash/links
+func (h *APIHandler) ListDeletedLinks(c *fiber.Ctx) error {
+	limit, offset := parsePagination(c)
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	links, err := h.linkService.ListDeletedLinks(ctx, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list deleted links", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list deleted links",
+		})
+	}
+
+	response := newLinkResponses(links)
+	return c.JSON(fiber.Map{
+		"links":  response,
+		"limit":  limit,
+		"offset": offset,
+		"count":  len(response),
+	})
+}
+
+func parsePagination(c *fiber.Ctx) (limit, offset int) {
+	limit = 20
+	offset = 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed := c.QueryInt("limit"); parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if parsed := c.QueryInt("offset"); parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	return limit, offset
+}
+
+func respondValidationError(c *fiber.Ctx, err *service.ValidationError) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":  "validation failed",
+		"fields": err.Fields,
+	})
+}
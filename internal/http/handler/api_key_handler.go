@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"go.uber.org/zap"
+)
+
+// CreateAPIKeyRequest represents the request body for creating an API key.
+// WorkspaceID defaults to the workspace the request acts in.
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	WorkspaceID string     `json:"workspace_id,omitempty"`
+	OwnerID     string     `json:"owner_id,omitempty"`
+}
+
+// APIKeyResponse describes an API key without its secret material.
+type APIKeyResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	WorkspaceID string     `json:"workspace_id"`
+	OwnerID     string     `json:"owner_id,omitempty"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newAPIKeyResponse(key *model.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		WorkspaceID: key.WorkspaceID,
+		OwnerID:     key.OwnerID,
+		Prefix:      key.Prefix,
+		Scopes:      key.Scopes,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+		CreatedAt:   key.CreatedAt,
+	}
+}
+
+// CreateAPIKey handles POST /api/keys
+// The plaintext key is only returned in this response.
+func (h *APIHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required",
+		})
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "expires_at must be in the future",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	workspaceID := strings.TrimSpace(req.WorkspaceID)
+	if actor, ok := service.ActorFromContext(ctx); ok && workspaceID == "" {
+		workspaceID = actor.WorkspaceID
+	}
+
+	key, token, err := h.apiKeyService.CreateKey(ctx, service.CreateAPIKeyInput{
+		Name:        req.Name,
+		Scopes:      req.Scopes,
+		ExpiresAt:   req.ExpiresAt,
+		WorkspaceID: workspaceID,
+		OwnerID:     strings.TrimSpace(req.OwnerID),
+	})
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			return respondValidationError(c, validationErr)
+		}
+		h.logger.Error("failed to create api key", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create api key",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"api_key": newAPIKeyResponse(key),
+		"key":     token,
+	})
+}
+
+// ListAPIKeys handles GET /api/keys
+func (h *APIHandler) ListAPIKeys(c *fiber.Ctx) error {
+	limit, offset := parsePagination(c)
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	keys, err := h.apiKeyService.ListKeys(ctx, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list api keys", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list api keys",
+		})
+	}
+
+	response := make([]APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = newAPIKeyResponse(&keys[i])
+	}
+
+	return c.JSON(fiber.Map{
+		"keys":   response,
+		"limit":  limit,
+		"offset": offset,
+		"count":  len(response),
+	})
+}
+
+// RevokeAPIKey handles DELETE /api/keys/:id
+func (h *APIHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.apiKeyService.RevokeKey(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "api key not found",
+			})
+		}
+		h.logger.Error("failed to revoke api key", zap.Error(err), zap.String("id", id))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to revoke api key",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
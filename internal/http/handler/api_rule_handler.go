@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	httpUtil "github.com/sifan077/PowerURL/internal/http/util"
+	"go.uber.org/zap"
+)
+
+// RuleRequest represents the request body for creating or replacing a routing rule.
+type RuleRequest struct {
+	Conditions  model.RuleConditions `json:"conditions"`
+	Destination string               `json:"destination"`
+	// Position is the zero-based index a new rule is inserted at; omitted appends it.
+	Position *int `json:"position,omitempty"`
+}
+
+func (r *RuleRequest) toRule() model.LinkRule {
+	return model.LinkRule{Conditions: r.Conditions, Destination: r.Destination}
+}
+
+// ReplaceRulesRequest represents the request body for PUT /api/links/:code/rules.
+type ReplaceRulesRequest struct {
+	Rules model.LinkRules `json:"rules"`
+}
+
+// ListRules handles GET /api/links/:code/rules
+// Rules are returned in evaluation order; the link's url is the default destination.
+func (h *APIHandler) ListRules(c *fiber.Ctx) error {
+	code := c.Params("code")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.GetLink(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to get link", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list rules",
+		})
+	}
+
+	return h.respondRules(c, fiber.StatusOK, link)
+}
+
+// ReplaceRules handles PUT /api/links/:code/rules
+// The body's rules replace the current list, which is how rules are reordered.
+// Rules keep their id when it is sent back; rules without one get a new id.
+func (h *APIHandler) ReplaceRules(c *fiber.Ctx) error {
+	code := c.Params("code")
+
+	var req ReplaceRulesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.ReplaceRules(ctx, code, req.Rules, httpUtil.ParseIfMatch(c.Get(fiber.HeaderIfMatch)))
+	if err != nil {
+		return h.respondRuleError(c, code, err)
+	}
+	return h.respondRules(c, fiber.StatusOK, link)
+}
+
+// CreateRule handles POST /api/links/:code/rules
+func (h *APIHandler) CreateRule(c *fiber.Ctx) error {
+	code := c.Params("code")
+
+	var req RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	position := -1
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "position must not be negative",
+			})
+		}
+		position = *req.Position
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.AddRule(ctx, code, req.toRule(), position, httpUtil.ParseIfMatch(c.Get(fiber.HeaderIfMatch)))
+	if err != nil {
+		return h.respondRuleError(c, code, err)
+	}
+	return h.respondRules(c, fiber.StatusCreated, link)
+}
+
+// UpdateRule handles PUT /api/links/:code/rules/:id
+// The rule keeps its id and position; conditions and destination are replaced.
+func (h *APIHandler) UpdateRule(c *fiber.Ctx) error {
+	code := c.Params("code")
+	ruleID := c.Params("id")
+
+	var req RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.UpdateRule(ctx, code, ruleID, req.toRule(), httpUtil.ParseIfMatch(c.Get(fiber.HeaderIfMatch)))
+	if err != nil {
+		return h.respondRuleError(c, code, err)
+	}
+	return h.respondRules(c, fiber.StatusOK, link)
+}
+
+// DeleteRule handles DELETE /api/links/:code/rules/:id
+func (h *APIHandler) DeleteRule(c *fiber.Ctx) error {
+	code := c.Params("code")
+	ruleID := c.Params("id")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.DeleteRule(ctx, code, ruleID, httpUtil.ParseIfMatch(c.Get(fiber.HeaderIfMatch)))
+	if err != nil {
+		return h.respondRuleError(c, code, err)
+	}
+	return h.respondRules(c, fiber.StatusOK, link)
+}
+
+func (h *APIHandler) respondRules(c *fiber.Ctx, status int, link *model.Link) error {
+	rules := link.Rules
+	if rules == nil {
+		rules = model.LinkRules{}
+	}
+	c.Set(fiber.HeaderETag, httpUtil.VersionETag(link.Version))
+	return c.Status(status).JSON(fiber.Map{
+		"rules":       rules,
+		"default_url": link.URL,
+		"version":     link.Version,
+	})
+}
+
+func (h *APIHandler) respondRuleError(c *fiber.Ctx, code string, err error) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return respondValidationError(c, validationErr)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"error": "link was modified since it was read",
+		})
+	case errors.Is(err, repository.ErrLinkNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "link not found",
+		})
+	case errors.Is(err, service.ErrRuleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "rule not found",
+		})
+	default:
+		h.logger.Error("failed to update rules", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update rules",
+		})
+	}
+}
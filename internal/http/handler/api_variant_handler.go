@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"go.uber.org/zap"
+)
+
+// VariantStatsResponse compares the click-through of one A/B variant.
+type VariantStatsResponse struct {
+	Variant     string `json:"variant"`
+	Destination string `json:"destination,omitempty"` // empty once the variant was removed from the link
+	Weight      int    `json:"weight"`
+	Visits      int64  `json:"visits"`
+	Successes   int64  `json:"successes"`
+	Pending     int64  `json:"pending"`
+	Failed      int64  `json:"failed"`
+	// ClickThroughRate is successes per visit. Direct-mode visits succeed
+	// immediately; intermediate modes only once the visitor continues.
+	ClickThroughRate float64 `json:"click_through_rate"`
+}
+
+// VariantStats handles GET /api/links/:code/variants/stats
+// Every current variant is listed, followed by removed variants that still have clicks.
+// Clicks classified as bots are left out unless include_bots=true.
+func (h *APIHandler) VariantStats(c *fiber.Ctx) error {
+	code := c.Params("code")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, err := h.linkService.GetLink(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "link not found",
+			})
+		}
+		h.logger.Error("failed to get link", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load variant stats",
+		})
+	}
+
+	stats, err := h.clickService.VariantStats(ctx, code, c.QueryBool("include_bots"))
+	if err != nil {
+		h.logger.Error("failed to load variant stats", zap.Error(err), zap.String("code", code))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load variant stats",
+		})
+	}
+
+	byVariant := make(map[string]repository.VariantStats, len(stats))
+	for _, stat := range stats {
+		byVariant[stat.Variant] = stat
+	}
+
+	variants := make([]VariantStatsResponse, 0, len(link.Variants)+len(stats))
+	for _, variant := range link.Variants {
+		resp := newVariantStatsResponse(byVariant[variant.ID])
+		resp.Variant = variant.ID
+		resp.Destination = variant.Destination
+		resp.Weight = variant.Weight
+		variants = append(variants, resp)
+		delete(byVariant, variant.ID)
+	}
+	for _, stat := range stats {
+		if _, removed := byVariant[stat.Variant]; removed {
+			variants = append(variants, newVariantStatsResponse(stat))
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"code":     link.Code,
+		"mode":     link.Mode,
+		"variants": variants,
+	})
+}
+
+func newVariantStatsResponse(stat repository.VariantStats) VariantStatsResponse {
+	resp := VariantStatsResponse{
+		Variant:   stat.Variant,
+		Visits:    stat.Visits,
+		Successes: stat.Successes,
+		Pending:   stat.Pending,
+		Failed:    stat.Failed,
+	}
+	if stat.Visits > 0 {
+		resp.ClickThroughRate = float64(stat.Successes) / float64(stat.Visits)
+	}
+	return resp
+}
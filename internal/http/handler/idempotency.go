@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"github.com/sifan077/PowerURL/internal/http/middleware"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeaderIdempotencyKey lets clients retry a create without creating duplicates.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks responses served from the idempotency store.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are stored with an idempotent response and sent again on replay.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag}
+
+// idempotent runs next at most once per Idempotency-Key within the configured window.
+// Retries with the same body get the stored response; a different body is rejected
+// with 422 and a retry while the first request is still running with 409.
+func (h *APIHandler) idempotent(c *fiber.Ctx, key string, next fiber.Handler) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Idempotency-Key must be at most 255 characters",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	storeKey := idempotencyScope(c) + ":" + key
+	hash := requestHash(c)
+
+	record, acquired, err := h.idempotency.Begin(ctx, storeKey, hash)
+	if err != nil {
+		h.logger.Error("idempotency store unavailable", zap.Error(err))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "idempotency store unavailable, retry later",
+		})
+	}
+	if !acquired {
+		switch {
+		case record.RequestHash != hash:
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Idempotency-Key was already used with a different request body",
+			})
+		case !record.Completed:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "a request with this Idempotency-Key is still in progress",
+			})
+		}
+		for name, value := range record.Headers {
+			c.Set(name, value)
+		}
+		c.Set(HeaderIdempotentReplayed, "true")
+		return c.Status(record.Status).Send(record.Body)
+	}
+
+	if err := next(c); err != nil {
+		h.releaseIdempotencyKey(storeKey)
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError {
+		// Server-side failures are not final; let the client retry with the same key.
+		h.releaseIdempotencyKey(storeKey)
+		return nil
+	}
+
+	completed := &repository.IdempotencyRecord{
+		RequestHash: hash,
+		Status:      status,
+		Headers:     make(map[string]string, len(replayedHeaders)),
+		Body:        append([]byte(nil), c.Response().Body()...),
+	}
+	for _, name := range replayedHeaders {
+		if value := c.GetRespHeader(name); value != "" {
+			completed.Headers[name] = value
+		}
+	}
+	if err := h.idempotency.Complete(ctx, storeKey, completed, h.idempotencyWindow); err != nil {
+		h.logger.Error("failed to store idempotent response", zap.Error(err))
+	}
+	return nil
+}
+
+func (h *APIHandler) releaseIdempotencyKey(storeKey string) {
+	if err := h.idempotency.Release(context.Background(), storeKey); err != nil {
+		h.logger.Warn("failed to release idempotency key", zap.Error(err))
+	}
+}
+
+// idempotencyScope keeps keys from different callers apart.
+func idempotencyScope(c *fiber.Ctx) string {
+	if key := middleware.APIKeyFromContext(c); key != nil {
+		return "key:" + key.ID
+	}
+	if ctx := c.UserContext(); ctx != nil {
+		if actor, ok := service.ActorFromContext(ctx); ok {
+			return "ws:" + actor.WorkspaceID
+		}
+	}
+	return "anonymous"
+}
+
+func requestHash(c *fiber.Ctx) string {
+	sum := sha256.New()
+	sum.Write([]byte(c.Method()))
+	sum.Write([]byte{0})
+	sum.Write([]byte(c.Path()))
+	sum.Write([]byte{0})
+	sum.Write([]byte(c.Get(middleware.HeaderWorkspaceID)))
+	sum.Write([]byte{0})
+	sum.Write(c.Body())
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"github.com/sifan077/PowerURL/internal/http/middleware"
+	"go.uber.org/zap"
+)
+
+// memoryIdempotencyStore mirrors the Redis store without expiry.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]repository.IdempotencyRecord
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, key, requestHash string) (*repository.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok {
+		return &record, false, nil
+	}
+	s.records[key] = repository.IdempotencyRecord{RequestHash: requestHash}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, record *repository.IdempotencyRecord, window time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.Completed = true
+	s.records[key] = *record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// stubLinkService creates links through createFn; other methods are not used by these tests.
+type stubLinkService struct {
+	service.LinkService
+	createFn func(input service.CreateLinkInput) (*model.Link, error)
+}
+
+func (s stubLinkService) CreateLink(ctx context.Context, input service.CreateLinkInput) (*model.Link, error) {
+	return s.createFn(input)
+}
+
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(ctx context.Context, token string) (*model.APIKey, error) {
+	return &model.APIKey{ID: "key-" + token, Scopes: []string{model.ScopeLinksWrite}}, nil
+}
+
+func newIdempotencyTestApp(createFn func(input service.CreateLinkInput) (*model.Link, error)) *fiber.App {
+	app := fiber.New()
+	NewAPIHandler(APIDeps{
+		LinkService:       stubLinkService{createFn: createFn},
+		Idempotency:       &memoryIdempotencyStore{records: map[string]repository.IdempotencyRecord{}},
+		IdempotencyWindow: time.Hour,
+		Auth:              []fiber.Handler{middleware.APIKeyAuth(stubAuthenticator{}, zap.NewNop())},
+	}).Register(app)
+	return app
+}
+
+type createResult struct {
+	status   int
+	body     string
+	etag     string
+	replayed bool
+}
+
+func postLink(t *testing.T, app *fiber.App, key, body string) createResult {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/api/links", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer alice")
+	req.Header.Set(HeaderIdempotencyKey, key)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("POST /api/links: %v", err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return createResult{
+		status:   resp.StatusCode,
+		body:     string(data),
+		etag:     resp.Header.Get(fiber.HeaderETag),
+		replayed: resp.Header.Get(HeaderIdempotentReplayed) == "true",
+	}
+}
+
+func TestCreateLink_IdempotentReplay(t *testing.T) {
+	created := 0
+	app := newIdempotencyTestApp(func(input service.CreateLinkInput) (*model.Link, error) {
+		created++
+		return &model.Link{Code: "abc123", URL: input.URL, Version: 1}, nil
+	})
+
+	const body = `{"url":"https://example.com"}`
+	first := postLink(t, app, "k1", body)
+	if first.status != fiber.StatusCreated || first.replayed {
+		t.Fatalf("expected the first request to create the link, got %d (replayed %v)", first.status, first.replayed)
+	}
+
+	second := postLink(t, app, "k1", body)
+	if !second.replayed {
+		t.Fatal("expected the retry to be marked as replayed")
+	}
+	if second.status != first.status || second.body != first.body || second.etag != first.etag {
+		t.Fatalf("expected the replay to match the first response, got %+v want %+v", second, first)
+	}
+	if created != 1 {
+		t.Fatalf("expected one link to be created, got %d", created)
+	}
+
+	if other := postLink(t, app, "k2", body); other.status != fiber.StatusCreated || other.replayed || created != 2 {
+		t.Fatalf("expected another key to create a new link, got %d after %d creates", other.status, created)
+	}
+}
+
+func TestCreateLink_IdempotencyKeyWithDifferentBody(t *testing.T) {
+	created := 0
+	app := newIdempotencyTestApp(func(input service.CreateLinkInput) (*model.Link, error) {
+		created++
+		return &model.Link{Code: "abc123", URL: input.URL, Version: 1}, nil
+	})
+
+	postLink(t, app, "k1", `{"url":"https://example.com"}`)
+	result := postLink(t, app, "k1", `{"url":"https://example.org"}`)
+	if result.status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a reused key with a different body, got %d", result.status)
+	}
+	if created != 1 {
+		t.Fatalf("expected the second body not to create a link, got %d creates", created)
+	}
+}
+
+func TestCreateLink_IdempotencyKeyInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app := newIdempotencyTestApp(func(input service.CreateLinkInput) (*model.Link, error) {
+		close(started)
+		<-release
+		return &model.Link{Code: "abc123", URL: input.URL, Version: 1}, nil
+	})
+
+	const body = `{"url":"https://example.com"}`
+	done := make(chan createResult)
+	go func() { done <- postLink(t, app, "k1", body) }()
+	<-started
+
+	if result := postLink(t, app, "k1", body); result.status != fiber.StatusConflict {
+		t.Fatalf("expected 409 while the first request is in progress, got %d", result.status)
+	}
+
+	close(release)
+	if first := <-done; first.status != fiber.StatusCreated {
+		t.Fatalf("expected the first request to finish, got %d", first.status)
+	}
+	if result := postLink(t, app, "k1", body); result.status != fiber.StatusCreated || !result.replayed {
+		t.Fatalf("expected the finished response to be replayed, got %d (replayed %v)", result.status, result.replayed)
+	}
+}
+
+func TestCreateLink_IdempotencyKeyReleasedAfterServerError(t *testing.T) {
+	fail := true
+	created := 0
+	app := newIdempotencyTestApp(func(input service.CreateLinkInput) (*model.Link, error) {
+		if fail {
+			return nil, errors.New("database unavailable")
+		}
+		created++
+		return &model.Link{Code: "abc123", URL: input.URL, Version: 1}, nil
+	})
+
+	const body = `{"url":"https://example.com"}`
+	if result := postLink(t, app, "k1", body); result.status != fiber.StatusInternalServerError {
+		t.Fatalf("expected the failing create to answer 500, got %d", result.status)
+	}
+
+	fail = false
+	result := postLink(t, app, "k1", body)
+	if result.status != fiber.StatusCreated || result.replayed {
+		t.Fatalf("expected the retry to run again after a 5xx, got %d (replayed %v)", result.status, result.replayed)
+	}
+	if created != 1 {
+		t.Fatalf("expected the retry to create the link, got %d creates", created)
+	}
+}
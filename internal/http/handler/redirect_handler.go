@@ -4,36 +4,69 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/google/uuid"
 	"github.com/sifan077/PowerURL/internal/app/model"
 	"github.com/sifan077/PowerURL/internal/app/repository"
 	"github.com/sifan077/PowerURL/internal/app/service"
 	httpUtil "github.com/sifan077/PowerURL/internal/http/util"
 	"github.com/sifan077/PowerURL/internal/http/view"
+	"github.com/sifan077/PowerURL/internal/infra/geoip"
 	"go.uber.org/zap"
 )
 
 const tokenTTL = 60 * time.Second
 
+// variantCookie keeps a returning visitor on the A/B variant they were first sent to.
+// It is scoped to the link's path and signed for the link's code.
+const (
+	variantCookie       = "pu_variant"
+	variantCookieMaxAge = 30 * 24 * time.Hour
+)
+
 // RedirectDeps groups dependencies required by redirect handlers.
 type RedirectDeps struct {
-	Logger         *zap.Logger
-	Links          repository.LinkRepository
-	ClickEvents    repository.ClickEventRepository
-	Secret         []byte
+	Logger      *zap.Logger
+	Links       repository.LinkRepository
+	ClickEvents repository.ClickEventRepository
+	Secret      []byte
+	// ClickPublisher records click events and classifies them; clicks classified as
+	// bots are not counted against the link's clicks. Nil treats every click as human.
 	ClickPublisher *service.ClickPublisher
+	ClickCounter   repository.ClickCounter
+	// NotYetActiveURL receives visitors of links whose StartsAt lies in the future.
+	// When empty a "not yet active" page is shown instead.
+	NotYetActiveURL string
+	// PasswordAttempts throttles guesses on password-mode links; without it they are not throttled.
+	PasswordAttempts repository.PasswordAttemptLimiter
+	// RulesLocation is the timezone day and time-of-day routing conditions refer to (default UTC).
+	RulesLocation *time.Location
+	// GeoIP resolves visitor countries for geo targets and click events; nil disables both.
+	GeoIP *geoip.Database
+	// Crawlers recognises social crawlers, which get a preview card instead of the
+	// destination and are not counted as clicks; nil treats every visitor alike.
+	Crawlers *service.CrawlerDetector
 }
 
 // RedirectHandler implements the redirect + intermediate flows.
 type RedirectHandler struct {
-	logger         *zap.Logger
-	links          repository.LinkRepository
-	clickEvents    repository.ClickEventRepository
-	tokens         *httpUtil.TokenSigner
-	clickPublisher *service.ClickPublisher
+	logger           *zap.Logger
+	links            repository.LinkRepository
+	clickEvents      repository.ClickEventRepository
+	tokens           *httpUtil.TokenSigner
+	clickPublisher   *service.ClickPublisher
+	clickCounter     repository.ClickCounter
+	notYetActiveURL  string
+	passwordAttempts repository.PasswordAttemptLimiter
+	rulesLocation    *time.Location
+	geoIP            *geoip.Database
+	crawlers         *service.CrawlerDetector
 }
 
 // NewRedirectHandler creates a redirect handler with the provided dependencies.
@@ -42,12 +75,22 @@ func NewRedirectHandler(deps RedirectDeps) *RedirectHandler {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
+	location := deps.RulesLocation
+	if location == nil {
+		location = time.UTC
+	}
 	return &RedirectHandler{
-		logger:         logger,
-		links:          deps.Links,
-		clickEvents:    deps.ClickEvents,
-		tokens:         httpUtil.NewTokenSigner(deps.Secret, tokenTTL),
-		clickPublisher: deps.ClickPublisher,
+		logger:           logger,
+		links:            deps.Links,
+		clickEvents:      deps.ClickEvents,
+		tokens:           httpUtil.NewTokenSigner(deps.Secret, tokenTTL),
+		clickPublisher:   deps.ClickPublisher,
+		clickCounter:     deps.ClickCounter,
+		notYetActiveURL:  deps.NotYetActiveURL,
+		passwordAttempts: deps.PasswordAttempts,
+		rulesLocation:    location,
+		geoIP:            deps.GeoIP,
+		crawlers:         deps.Crawlers,
 	}
 }
 
@@ -56,7 +99,12 @@ func (h *RedirectHandler) Register(router fiber.Router) {
 	router.Get("/", h.Health)
 	router.Get("/health", h.Health)
 	router.Get("/:code", h.Resolve)
+	router.Post("/:code", h.VerifyPassword)
+	// The token route is registered before the wildcard routes of prefix links, and
+	// paths whose first segment is _go are never forwarded, so it stays unambiguous.
 	router.Get("/:code/_go/:token", h.Go)
+	router.Get("/:code/*", h.Resolve)
+	router.Post("/:code/*", h.VerifyPassword)
 }
 
 // Health is a simple root endpoint so we know the service is running.
@@ -84,30 +132,66 @@ func (h *RedirectHandler) Resolve(c *fiber.Ctx) error {
 
 	link, loadErr := h.loadLink(ctx, code)
 	if loadErr != nil {
-		return c.Status(loadErr.StatusCode).JSON(fiber.Map{
-			"error": loadErr.Message,
+		return h.respondLoadError(c, code, loadErr)
+	}
+
+	suffix, ok := forwardedPath(c, link)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "short link not found",
 		})
 	}
+	if h.crawlers.IsCrawler(c.Get(fiber.HeaderUserAgent)) {
+		// Unfurling a shared link is not a visit: no routing, no click.
+		return h.renderSocialCard(c, link)
+	}
+
+	rt := h.routeVisit(c, link, h.matchRule(c, link))
+	rt.Path = suffix
+
+	if link.FlagAction == service.FlagActionWarn && link.Mode != service.ModePassword {
+		// The warning replaces any intermediate page; the click stays pending until the
+		// visitor chooses to continue. Password pages show the warning themselves.
+		event := h.newClickEvent(ctx, c, code, model.ClickStatusPending, rt)
+		ref := clickRef{ClickID: uuid.New().String(), Bot: event.IsBot, Route: rt.Route, Path: rt.Path}
+		if h.clickPublisher != nil {
+			event.ID = ref.ClickID
+			go h.publishClickEvent(event)
+		}
+		return h.renderWarningPage(c, link, ref, rt)
+	}
 
 	switch link.Mode {
 	case "", "direct":
+		event := h.newClickEvent(ctx, c, code, model.ClickStatusSuccess, rt)
+		if !event.IsBot {
+			if claimErr := h.claimClick(ctx, link, ""); claimErr != nil {
+				return c.Status(claimErr.StatusCode).JSON(fiber.Map{
+					"error": claimErr.Message,
+				})
+			}
+		}
+		clickID := uuid.New().String()
+		target := h.destinationURL(c, link, rt, clickID)
 		// Publish click event for direct mode with success status
 		if h.clickPublisher != nil {
-			ip := c.IP()
-			userAgent := c.Get("User-Agent")
-			go h.publishClickEvent(code, ip, userAgent, model.ClickStatusSuccess, "")
+			event.ID = clickID
+			go h.publishClickEvent(event)
 		}
-		h.logger.Debug("redirecting short link", zap.String("code", code), zap.String("target", link.URL))
-		return c.Redirect(link.URL, fiber.StatusFound)
+		h.logger.Debug("redirecting short link", zap.String("code", code), zap.String("target", target))
+		return c.Redirect(target, fiber.StatusFound)
 	case "click", "timer":
 		// Publish click event for intermediate modes with pending status
-		clickID := uuid.New().String()
+		event := h.newClickEvent(ctx, c, code, model.ClickStatusPending, rt)
+		ref := clickRef{ClickID: uuid.New().String(), Bot: event.IsBot, Route: rt.Route, Path: rt.Path}
 		if h.clickPublisher != nil {
-			ip := c.IP()
-			userAgent := c.Get("User-Agent")
-			go h.publishClickEvent(code, ip, userAgent, model.ClickStatusPending, clickID)
+			event.ID = ref.ClickID
+			go h.publishClickEvent(event)
 		}
-		return h.renderIntermediateWithClickID(c, link, clickID)
+		return h.renderIntermediateWithClickID(c, link, ref, rt)
+	case service.ModePassword:
+		// The click is only recorded once the password was accepted.
+		return h.renderPasswordPage(c, link, rt, fiber.StatusOK, "")
 	default:
 		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
 			"error": "redirect mode is not supported",
@@ -126,7 +210,7 @@ func (h *RedirectHandler) Go(c *fiber.Ctx) error {
 		})
 	}
 
-	clickID, err := h.tokens.ValidateAndExtractClickID(code, token)
+	refValue, err := h.tokens.ValidateAndExtractClickID(code, token)
 	if err != nil {
 		if errors.Is(err, httpUtil.ErrInvalidToken) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -146,26 +230,143 @@ func (h *RedirectHandler) Go(c *fiber.Ctx) error {
 
 	link, loadErr := h.loadLink(ctx, code)
 	if loadErr != nil {
-		return c.Status(loadErr.StatusCode).JSON(fiber.Map{
-			"error": loadErr.Message,
-		})
+		return h.respondLoadError(c, code, loadErr)
+	}
+
+	// The click was classified when it started; bots are not counted.
+	ref := parseClickRef(refValue)
+	if !ref.Bot {
+		if claimErr := h.claimClick(ctx, link, ref.ClickID); claimErr != nil {
+			return c.Status(claimErr.StatusCode).JSON(fiber.Map{
+				"error": claimErr.Message,
+			})
+		}
 	}
 
 	// Update click event status to success if click ID is present
-	if clickID != "" && h.clickEvents != nil {
+	if ref.ClickID != "" && h.clickEvents != nil {
 		go func() {
-			if err := h.clickEvents.UpdateStatus(ctx, clickID, model.ClickStatusSuccess); err != nil {
-				h.logger.Error("failed to update click event status", zap.Error(err), zap.String("click_id", clickID))
+			if err := h.clickEvents.UpdateStatus(ctx, ref.ClickID, model.ClickStatusSuccess); err != nil {
+				h.logger.Error("failed to update click event status", zap.Error(err), zap.String("click_id", ref.ClickID))
 			}
 		}()
 	}
 
-	h.logger.Debug("final redirect", zap.String("code", code), zap.String("target", link.URL))
-	return c.Redirect(link.URL, fiber.StatusFound)
+	rt := route{Route: ref.Route, Country: h.geoIP.Lookup(c.IP()).Country, Path: ref.Path}
+	target := h.destinationURL(c, link, rt, ref.ClickID)
+	h.logger.Debug("final redirect", zap.String("code", code), zap.String("target", target))
+	return c.Redirect(target, fiber.StatusFound)
 }
 
-func (h *RedirectHandler) renderIntermediateWithClickID(c *fiber.Ctx, link *model.Link, clickID string) error {
-	token, err := h.tokens.IssueWithClickID(link.Code, clickID)
+// VerifyPassword handles POST /:code for password-mode links. A correct password
+// earns a signed token for the regular /:code/_go/:token redirect.
+func (h *RedirectHandler) VerifyPassword(c *fiber.Ctx) error {
+	code := c.Params("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "missing link code",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	link, loadErr := h.loadLink(ctx, code)
+	if loadErr != nil {
+		return h.respondLoadError(c, code, loadErr)
+	}
+	if link.Mode != service.ModePassword {
+		return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{
+			"error": "link is not password protected",
+		})
+	}
+	suffix, ok := forwardedPath(c, link)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "short link not found",
+		})
+	}
+
+	// The rule matched when the form was shown. It is as client-controlled as the
+	// headers it was matched on, and unknown IDs fall back to the link's URL.
+	ruleID := c.FormValue("rule")
+	if link.Rules.Rule(ruleID) == nil {
+		ruleID = ""
+	}
+	rt := h.routeVisit(c, link, ruleID)
+	rt.Path = suffix
+
+	if h.passwordAttempts != nil {
+		allowed, retryAfter, err := h.passwordAttempts.Begin(ctx, code)
+		if err != nil {
+			// Fail closed: an unthrottled form would invite brute force.
+			h.logger.Error("failed to throttle password attempt", zap.Error(err), zap.String("code", code))
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "password check unavailable",
+			})
+		}
+		if !allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())+1))
+			return h.renderPasswordPage(c, link, rt, fiber.StatusTooManyRequests, "Too many attempts. Please try again later.")
+		}
+	}
+
+	if !service.CheckLinkPassword(link, c.FormValue("password")) {
+		return h.renderPasswordPage(c, link, rt, fiber.StatusForbidden, "Incorrect password.")
+	}
+
+	if h.passwordAttempts != nil {
+		if err := h.passwordAttempts.Succeeded(ctx, code); err != nil {
+			h.logger.Warn("failed to refund password attempt", zap.Error(err), zap.String("code", code))
+		}
+	}
+
+	event := h.newClickEvent(ctx, c, code, model.ClickStatusPending, rt)
+	ref := clickRef{ClickID: uuid.New().String(), Bot: event.IsBot, Route: rt.Route, Path: rt.Path}
+	token, err := h.tokens.IssueWithClickID(link.Code, ref.String())
+	if err != nil {
+		h.logger.Error("failed to issue redirect token", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to prepare redirect",
+		})
+	}
+	if h.clickPublisher != nil {
+		event.ID = ref.ClickID
+		go h.publishClickEvent(event)
+	}
+
+	return c.Redirect(withVisitorQuery(c, fmt.Sprintf("/%s/_go/%s", link.Code, token)), fiber.StatusSeeOther)
+}
+
+func (h *RedirectHandler) renderPasswordPage(c *fiber.Ctx, link *model.Link, rt route, status int, message string) error {
+	data := view.PasswordPageData{
+		Code:   link.Code,
+		Action: withVisitorQuery(c, "/"+link.Code+rt.Path),
+		RuleID: rt.RuleID,
+		Error:  message,
+	}
+	if link.FlagAction == service.FlagActionWarn {
+		data.Warning = "this link leads to a site that has been reported as malicious."
+	}
+	html, err := view.RenderPasswordPage(data)
+	if err != nil {
+		h.logger.Error("failed to render password page", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to render page",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.
+		Status(status).
+		Type("html", "utf-8").
+		SendString(html)
+}
+
+func (h *RedirectHandler) renderIntermediateWithClickID(c *fiber.Ctx, link *model.Link, ref clickRef, rt route) error {
+	token, err := h.tokens.IssueWithClickID(link.Code, ref.String())
 	if err != nil {
 		h.logger.Error("failed to issue redirect token", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -173,17 +374,23 @@ func (h *RedirectHandler) renderIntermediateWithClickID(c *fiber.Ctx, link *mode
 		})
 	}
 
-	continueURL := fmt.Sprintf("/%s/_go/%s", link.Code, token)
-	html, err := view.RenderRedirectPage(view.RedirectPageData{
+	continueURL := withVisitorQuery(c, fmt.Sprintf("/%s/_go/%s", link.Code, token))
+	data := view.RedirectPageData{
 		Title:        "Continue to destination",
 		Code:         link.Code,
-		TargetURL:    link.URL,
+		TargetURL:    h.destinationURL(c, link, rt, ref.ClickID),
 		ContinueURL:  continueURL,
 		Mode:         link.Mode,
 		TimerSeconds: link.TimerSeconds,
 		Token:        token,
-		ClickID:      clickID,
-	})
+		ClickID:      ref.ClickID,
+	}
+	// The preview describes the main URL only; rules, geo targets and variants go elsewhere.
+	if link.Destination(rt.Route) == link.URL && link.Preview.Describes(link.URL) {
+		data.PreviewTitle = link.Preview.Title
+		data.FaviconURL = link.Preview.FaviconURL
+	}
+	html, err := view.RenderRedirectPage(data)
 	if err != nil {
 		h.logger.Error("failed to render redirect page", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -196,9 +403,112 @@ func (h *RedirectHandler) renderIntermediateWithClickID(c *fiber.Ctx, link *mode
 		SendString(html)
 }
 
+// renderSocialCard serves crawlers the link's social overrides, falling back to the
+// fetched preview of its destination.
+func (h *RedirectHandler) renderSocialCard(c *fiber.Ctx, link *model.Link) error {
+	data := view.SocialCardData{
+		URL:         c.BaseURL() + c.Path(),
+		Title:       link.Social.Title,
+		Description: link.Social.Description,
+		ImageURL:    link.Social.ImageURL,
+	}
+	switch {
+	case link.FlagAction == service.FlagActionWarn:
+		// Do not advertise a destination that has been reported as malicious.
+		data = view.SocialCardData{URL: data.URL, Title: "Warning: suspicious destination"}
+	case link.Mode != service.ModePassword && link.Preview.Describes(link.URL):
+		// Password-protected destinations stay private; only the overrides are shown.
+		if data.Title == "" {
+			data.Title = link.Preview.Title
+		}
+		if data.Description == "" {
+			data.Description = link.Preview.Description
+		}
+		if data.ImageURL == "" {
+			data.ImageURL = link.Preview.ImageURL
+		}
+	}
+	if data.Title == "" {
+		data.Title = c.Hostname() + "/" + link.Code
+	}
+
+	html, err := view.RenderSocialCard(data)
+	if err != nil {
+		h.logger.Error("failed to render social card", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to render page",
+		})
+	}
+
+	return c.
+		Type("html", "utf-8").
+		SendString(html)
+}
+
+func (h *RedirectHandler) renderWarningPage(c *fiber.Ctx, link *model.Link, ref clickRef, rt route) error {
+	token, err := h.tokens.IssueWithClickID(link.Code, ref.String())
+	if err != nil {
+		h.logger.Error("failed to issue redirect token", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to prepare redirect",
+		})
+	}
+
+	html, err := view.RenderWarningPage(view.WarningPageData{
+		Code:        link.Code,
+		TargetURL:   h.destinationURL(c, link, rt, ref.ClickID),
+		ContinueURL: withVisitorQuery(c, fmt.Sprintf("/%s/_go/%s", link.Code, token)),
+	})
+	if err != nil {
+		h.logger.Error("failed to render warning page", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to render page",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.
+		Type("html", "utf-8").
+		SendString(html)
+}
+
 type linkLoadError struct {
 	StatusCode int
 	Message    string
+	// StartsAt is set when the link exists but is not active yet.
+	StartsAt *time.Time
+}
+
+// respondLoadError renders a loadLink failure. Scheduled links get the fallback URL or
+// the "not yet active" page; everything else a JSON error.
+func (h *RedirectHandler) respondLoadError(c *fiber.Ctx, code string, loadErr *linkLoadError) error {
+	if loadErr.StartsAt == nil {
+		return c.Status(loadErr.StatusCode).JSON(fiber.Map{
+			"error": loadErr.Message,
+		})
+	}
+
+	if h.notYetActiveURL != "" {
+		return c.Redirect(h.notYetActiveURL, fiber.StatusFound)
+	}
+
+	html, err := view.RenderNotYetActivePage(view.NotYetActivePageData{
+		Code:     code,
+		StartsAt: *loadErr.StartsAt,
+	})
+	if err != nil {
+		h.logger.Error("failed to render not yet active page", zap.Error(err))
+		return c.Status(loadErr.StatusCode).JSON(fiber.Map{
+			"error": loadErr.Message,
+		})
+	}
+
+	retryAfter := int(time.Until(*loadErr.StartsAt).Seconds()) + 1
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.
+		Status(loadErr.StatusCode).
+		Type("html", "utf-8").
+		SendString(html)
 }
 
 func (h *RedirectHandler) loadLink(ctx context.Context, code string) (*model.Link, *linkLoadError) {
@@ -223,27 +533,265 @@ func (h *RedirectHandler) loadLink(ctx context.Context, code string) (*model.Lin
 			Message:    "link is disabled",
 		}
 	}
+	if link.FlagAction == service.FlagActionDisable {
+		return nil, &linkLoadError{
+			StatusCode: fiber.StatusGone,
+			Message:    "link is disabled pending a safety review",
+		}
+	}
+	if now := time.Now(); link.NotYetActive(now) {
+		return nil, &linkLoadError{
+			// 503 with Retry-After tells clients and crawlers to come back later.
+			StatusCode: fiber.StatusServiceUnavailable,
+			Message:    "link is not active yet",
+			StartsAt:   link.StartsAt,
+		}
+	}
 	if link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt) {
 		return nil, &linkLoadError{
 			StatusCode: fiber.StatusGone,
 			Message:    "link expired",
 		}
 	}
+	if link.MaxClicks != nil && h.clickCounter != nil {
+		count, err := h.clickCounter.Current(ctx, code)
+		if err != nil {
+			h.logger.Error("failed to load click count", zap.Error(err), zap.String("code", code))
+			return nil, &linkLoadError{
+				StatusCode: fiber.StatusServiceUnavailable,
+				Message:    "click limit unavailable",
+			}
+		}
+		if link.ClickCapped(count) {
+			return nil, clickLimitReached()
+		}
+	}
 
 	return link, nil
 }
 
-func (h *RedirectHandler) publishClickEvent(code, ip, userAgent, status, clickID string) {
-	h.publishClickEventWithRetry(code, ip, userAgent, status, clickID)
+// claimClick counts a successful redirect, refusing it once the link's MaxClicks is used up.
+// Uncapped links are still counted but never refused because of counter errors.
+// Clicks classified as bots are not claimed, so they cannot use up MaxClicks.
+// A clickID from a redirect token is counted once however often the token is replayed.
+func (h *RedirectHandler) claimClick(ctx context.Context, link *model.Link, clickID string) *linkLoadError {
+	if h.clickCounter == nil {
+		return nil
+	}
+
+	limit := 0
+	if link.MaxClicks != nil {
+		limit = *link.MaxClicks
+	}
+	var allowed bool
+	var err error
+	if clickID != "" {
+		_, allowed, err = h.clickCounter.ClaimOnce(ctx, link.Code, clickID, limit, tokenTTL)
+	} else {
+		_, allowed, err = h.clickCounter.Claim(ctx, link.Code, limit)
+	}
+	if err != nil {
+		h.logger.Error("failed to count click", zap.Error(err), zap.String("code", link.Code))
+		if limit > 0 {
+			return &linkLoadError{
+				StatusCode: fiber.StatusServiceUnavailable,
+				Message:    "click limit unavailable",
+			}
+		}
+		return nil
+	}
+	if !allowed {
+		return clickLimitReached()
+	}
+	return nil
+}
+
+func clickLimitReached() *linkLoadError {
+	return &linkLoadError{
+		StatusCode: fiber.StatusGone,
+		Message:    "link reached its click limit",
+	}
+}
+
+// matchRule evaluates the link's routing rules against the request and returns the
+// ID of the first matching rule, or "" when the link's URL applies.
+func (h *RedirectHandler) matchRule(c *fiber.Ctx, link *model.Link) string {
+	rule := service.MatchRule(link.Rules, service.RuleRequest{
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		AcceptLanguage: c.Get(fiber.HeaderAcceptLanguage),
+		Referrer:       c.Get(fiber.HeaderReferer),
+		Query:          c.Queries(),
+		Now:            time.Now().In(h.rulesLocation),
+	})
+	if rule == nil {
+		return ""
+	}
+	return rule.ID
+}
+
+// route records how a visit's destination was chosen.
+type route struct {
+	model.Route
+	Country string // visitor country, when the GeoIP database knows it
+	Path    string // suffix forwarded by a prefix link, from service.CleanForwardedPath
+}
+
+// forwardedPath returns the cleaned path a visitor appended to the link's code. ok
+// is false when the link does not forward paths or the path cannot be forwarded.
+func forwardedPath(c *fiber.Ctx, link *model.Link) (string, bool) {
+	raw := c.Params("*")
+	if raw == "" {
+		return "", true
+	}
+	if !link.ForwardPath || raw == "_go" || strings.HasPrefix(raw, "_go/") {
+		return "", false
+	}
+	return service.CleanForwardedPath(raw)
+}
+
+// routeVisit resolves the visitor's location and, unless ruleID already decided the
+// destination, the link's matching geo target or else its A/B variant.
+func (h *RedirectHandler) routeVisit(c *fiber.Ctx, link *model.Link, ruleID string) route {
+	loc := h.geoIP.Lookup(c.IP())
+	rt := route{Route: model.Route{RuleID: ruleID}, Country: loc.Country}
+	if ruleID == "" {
+		rt.GeoKey = link.GeoTargets.Match(loc.Country, loc.Region)
+	}
+	if rt.RuleID == "" && rt.GeoKey == "" && len(link.Variants) > 0 {
+		rt.VariantID = h.assignVariant(c, link)
+	}
+	return rt
+}
+
+// assignVariant returns the visitor's A/B variant of link and (re)sets the sticky
+// cookie whenever the assignment changes.
+func (h *RedirectHandler) assignVariant(c *fiber.Ctx, link *model.Link) string {
+	sticky := ""
+	if cookie := c.Cookies(variantCookie); cookie != "" {
+		if value, err := h.tokens.VerifyValue(link.Code, cookie); err == nil {
+			sticky = value
+		}
+	}
+
+	variant := service.AssignVariant(link.Variants, sticky)
+	if variant == nil {
+		return ""
+	}
+	if variant.ID != sticky {
+		signed, err := h.tokens.SignValue(link.Code, variant.ID)
+		if err != nil {
+			h.logger.Warn("failed to sign variant cookie", zap.Error(err), zap.String("code", link.Code))
+			return variant.ID
+		}
+		c.Cookie(&fiber.Cookie{
+			Name:     variantCookie,
+			Value:    signed,
+			Path:     "/" + link.Code,
+			MaxAge:   int(variantCookieMaxAge.Seconds()),
+			Secure:   c.Protocol() == "https",
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+	}
+	return variant.ID
+}
+
+// destinationURL expands the destination rt chose for this visit, appends the
+// forwarded path and applies the link's query policy to the visitor's query string.
+func (h *RedirectHandler) destinationURL(c *fiber.Ctx, link *model.Link, rt route, clickID string) string {
+	rawQuery := string(c.Request().URI().QueryString())
+	query, _ := url.ParseQuery(rawQuery)
+	target := service.ExpandDestination(link.Destination(rt.Route), service.TemplateVars{
+		ClickID: clickID,
+		Code:    link.Code,
+		Country: rt.Country,
+		Variant: rt.VariantID,
+		Query:   query,
+	})
+	target = service.JoinDestinationPath(target, rt.Path)
+	return service.ApplyQueryPolicy(target, link.QueryPolicy, rawQuery)
+}
+
+// withVisitorQuery carries the visitor's query string over to the next step of an
+// intermediate or password flow, where destinationURL reads it.
+func withVisitorQuery(c *fiber.Ctx, path string) string {
+	if rawQuery := c.Request().URI().QueryString(); len(rawQuery) > 0 {
+		return path + "?" + string(rawQuery)
+	}
+	return path
+}
+
+// clickRef is carried in signed redirect tokens from the page a visitor sees to the final redirect.
+type clickRef struct {
+	ClickID string
+	Bot     bool // the click was classified as automated and is not counted
+	model.Route
+	Path string
+}
+
+const botClickMarker = "bot"
+
+func (r clickRef) String() string {
+	if !r.Bot && r.Route == (model.Route{}) && r.Path == "" {
+		return r.ClickID
+	}
+	bot := ""
+	if r.Bot {
+		bot = botClickMarker
+	}
+	// Path goes last: it is the only part that may contain colons, as rule, geo and
+	// variant IDs are validated to be made of letters, digits, '-' and '_'.
+	return strings.Join([]string{r.ClickID, bot, r.RuleID, r.GeoKey, r.VariantID, r.Path}, ":")
+}
+
+func parseClickRef(value string) clickRef {
+	parts := strings.SplitN(value, ":", 6)
+	ref := clickRef{ClickID: parts[0]}
+	if len(parts) > 1 {
+		ref.Bot = parts[1] == botClickMarker
+	}
+	if len(parts) > 2 {
+		ref.RuleID = parts[2]
+	}
+	if len(parts) > 3 {
+		ref.GeoKey = parts[3]
+	}
+	if len(parts) > 4 {
+		ref.VariantID = parts[4]
+	}
+	if len(parts) > 5 {
+		ref.Path = parts[5]
+	}
+	return ref
+}
+
+// newClickEvent builds the click event of this request, which the click publisher
+// classifies as a bot or a person. Build it once per click, before it is counted.
+// Request values are copied: fiber reuses their memory once the handler returns,
+// while the event is published in the background.
+func (h *RedirectHandler) newClickEvent(ctx context.Context, c *fiber.Ctx, code, status string, rt route) model.ClickEvent {
+	ip, userAgent := utils.CopyString(c.IP()), utils.CopyString(c.Get(fiber.HeaderUserAgent))
+	event := h.clickPublisher.NewEvent(ctx, utils.CopyString(code), ip, userAgent, status, service.ClickSignals{
+		Accept:         c.Get(fiber.HeaderAccept),
+		AcceptLanguage: c.Get(fiber.HeaderAcceptLanguage),
+	})
+	event.RuleID = rt.RuleID
+	event.Country = rt.Country
+	event.Variant = rt.VariantID
+	return event
+}
+
+func (h *RedirectHandler) publishClickEvent(event model.ClickEvent) {
+	h.publishClickEventWithRetry(event)
 }
 
-func (h *RedirectHandler) publishClickEventWithRetry(code, ip, userAgent, status, clickID string) {
+func (h *RedirectHandler) publishClickEventWithRetry(event model.ClickEvent) {
 	const maxRetries = 3
 	const retryDelay = 100 * time.Millisecond
 
 	for i := 0; i < maxRetries; i++ {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		err := h.clickPublisher.PublishWithContext(ctx, code, ip, userAgent, status, clickID)
+		err := h.clickPublisher.PublishEvent(ctx, event)
 		cancel()
 
 		if err == nil {
@@ -253,7 +801,7 @@ func (h *RedirectHandler) publishClickEventWithRetry(code, ip, userAgent, status
 		if i < maxRetries-1 {
 			h.logger.Warn("failed to publish click event, retrying",
 				zap.Error(err),
-				zap.String("code", code),
+				zap.String("code", event.LinkCode),
 				zap.Int("attempt", i+1),
 				zap.Int("max_retries", maxRetries))
 			time.Sleep(retryDelay)
@@ -261,6 +809,6 @@ func (h *RedirectHandler) publishClickEventWithRetry(code, ip, userAgent, status
 	}
 
 	h.logger.Error("failed to publish click event after all retries",
-		zap.String("code", code),
-		zap.String("status", status))
+		zap.String("code", event.LinkCode),
+		zap.String("status", event.Status))
 }
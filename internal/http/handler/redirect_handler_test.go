@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	httpUtil "github.com/sifan077/PowerURL/internal/http/util"
+)
+
+// staticLinkRepository serves a single link; other methods are not used by these tests.
+type staticLinkRepository struct {
+	repository.LinkRepository
+	link model.Link
+}
+
+func (r staticLinkRepository) GetByCode(ctx context.Context, code string) (*model.Link, error) {
+	if code != r.link.Code {
+		return nil, repository.ErrLinkNotFound
+	}
+	link := r.link
+	return &link, nil
+}
+
+// memoryClickCounter counts claims in memory; other methods are not used by these tests.
+type memoryClickCounter struct {
+	repository.ClickCounter
+	counts  map[string]int64
+	claimed map[string]bool
+}
+
+func (c *memoryClickCounter) Current(ctx context.Context, code string) (int64, error) {
+	return c.counts[code], nil
+}
+
+func (c *memoryClickCounter) Claim(ctx context.Context, code string, limit int) (int64, bool, error) {
+	if limit > 0 && c.counts[code] >= int64(limit) {
+		return c.counts[code], false, nil
+	}
+	c.counts[code]++
+	return c.counts[code], true, nil
+}
+
+func (c *memoryClickCounter) ClaimOnce(ctx context.Context, code, clickID string, limit int, ttl time.Duration) (int64, bool, error) {
+	if c.claimed[clickID] {
+		return c.counts[code], true, nil
+	}
+	count, allowed, err := c.Claim(ctx, code, limit)
+	if allowed {
+		if c.claimed == nil {
+			c.claimed = map[string]bool{}
+		}
+		c.claimed[clickID] = true
+	}
+	return count, allowed, err
+}
+
+// recordingJetStream passes published click events to the test; other methods are
+// not used by these tests.
+type recordingJetStream struct {
+	nats.JetStreamContext
+	published chan model.ClickEvent
+}
+
+func (js *recordingJetStream) Publish(subj string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	var event model.ClickEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	js.published <- event
+	return &nats.PubAck{}, nil
+}
+
+// next waits for the next event, which the handler publishes in the background.
+func (js *recordingJetStream) next(t *testing.T) model.ClickEvent {
+	t.Helper()
+	select {
+	case event := <-js.published:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a click event to be published")
+		return model.ClickEvent{}
+	}
+}
+
+func TestRedirectHandler_BotClicksAreNotCounted(t *testing.T) {
+	maxClicks := 1
+	counter := &memoryClickCounter{counts: map[string]int64{}}
+	js := &recordingJetStream{published: make(chan model.ClickEvent, 8)}
+	app := fiber.New()
+	NewRedirectHandler(RedirectDeps{
+		Links:          staticLinkRepository{link: model.Link{Code: "promo", URL: "https://example.com", MaxClicks: &maxClicks}},
+		ClickCounter:   counter,
+		Secret:         []byte("secret"),
+		ClickPublisher: service.NewClickPublisher(js, service.NewBotClassifier(service.BotClassifierDeps{})),
+	}).Register(app)
+
+	visit := func(userAgent string) int {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, "/promo", nil)
+		req.Header.Set(fiber.HeaderUserAgent, userAgent)
+		req.Header.Set(fiber.HeaderAccept, "text/html,application/xhtml+xml")
+		req.Header.Set(fiber.HeaderAcceptLanguage, "en-US,en;q=0.9")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET /promo: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	for i := 0; i < 3; i++ {
+		if status := visit("curl/8.5.0"); status != fiber.StatusFound {
+			t.Fatalf("expected bots to be redirected, got %d", status)
+		}
+	}
+	if counter.counts["promo"] != 0 {
+		t.Fatalf("expected bot clicks not to be counted, got %d", counter.counts["promo"])
+	}
+	for i := 0; i < 3; i++ {
+		if event := js.next(t); !event.IsBot || event.BotReason != `User-Agent matches "curl/"` {
+			t.Fatalf("expected the bot click to be published as a bot, got %+v", event)
+		}
+	}
+
+	const browser = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/124.0 Safari/537.36"
+	if status := visit(browser); status != fiber.StatusFound {
+		t.Fatalf("expected the first human click to be redirected, got %d", status)
+	}
+	if event := js.next(t); event.IsBot || event.Status != model.ClickStatusSuccess {
+		t.Fatalf("expected the human click to be published as a success, got %+v", event)
+	}
+	if status := visit(browser); status != fiber.StatusGone {
+		t.Fatalf("expected the click limit to be reached by human clicks, got %d", status)
+	}
+}
+
+func TestRedirectHandler_ReplayedTokenIsCountedOnce(t *testing.T) {
+	maxClicks := 2
+	counter := &memoryClickCounter{counts: map[string]int64{}}
+	app := fiber.New()
+	NewRedirectHandler(RedirectDeps{
+		Links:        staticLinkRepository{link: model.Link{Code: "promo", URL: "https://example.com", MaxClicks: &maxClicks}},
+		ClickCounter: counter,
+		Secret:       []byte("secret"),
+	}).Register(app)
+
+	signer := httpUtil.NewTokenSigner([]byte("secret"), tokenTTL)
+	follow := func(clickID string) int {
+		t.Helper()
+		token, err := signer.IssueWithClickID("promo", clickRef{ClickID: clickID}.String())
+		if err != nil {
+			t.Fatalf("IssueWithClickID error: %v", err)
+		}
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/promo/_go/"+token, nil))
+		if err != nil {
+			t.Fatalf("GET /promo/_go: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	for i := 0; i < 3; i++ {
+		if status := follow("c1"); status != fiber.StatusFound {
+			t.Fatalf("expected replay %d of the token to be redirected, got %d", i, status)
+		}
+	}
+	if counter.counts["promo"] != 1 {
+		t.Fatalf("expected the replayed click to be counted once, got %d", counter.counts["promo"])
+	}
+
+	if status := follow("c2"); status != fiber.StatusFound {
+		t.Fatalf("expected a second click to be redirected, got %d", status)
+	}
+	if status := follow("c3"); status != fiber.StatusGone {
+		t.Fatalf("expected the click limit to be reached by distinct clicks, got %d", status)
+	}
+}
+
+func TestClickRef_RoundTrip(t *testing.T) {
+	for _, ref := range []clickRef{
+		{ClickID: "c1"},
+		{ClickID: "c2", Bot: true},
+		{ClickID: "c3", Route: model.Route{RuleID: "r", GeoKey: "DE", VariantID: "b"}, Path: "/docs:v2"},
+		{ClickID: "c4", Bot: true, Path: "/a"},
+	} {
+		if got := parseClickRef(ref.String()); got != ref {
+			t.Errorf("parseClickRef(%q) = %+v, want %+v", ref.String(), got, ref)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/repository"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"go.uber.org/zap"
+)
+
+// WorkspaceRequest represents the request body for creating or renaming a workspace.
+type WorkspaceRequest struct {
+	Name    string `json:"name"`
+	OwnerID string `json:"owner_id,omitempty"`
+}
+
+// WorkspaceMemberRequest represents the request body for adding or updating a member.
+type WorkspaceMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// CreateWorkspace handles POST /api/workspaces
+// owner_id, when given, is added as the workspace's first member with the owner role.
+func (h *APIHandler) CreateWorkspace(c *fiber.Ctx) error {
+	var req WorkspaceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	workspace, err := h.workspaceService.CreateWorkspace(ctx, req.Name, req.OwnerID)
+	if err != nil {
+		h.logger.Error("failed to create workspace", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create workspace",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(workspace)
+}
+
+// ListWorkspaces handles GET /api/workspaces
+func (h *APIHandler) ListWorkspaces(c *fiber.Ctx) error {
+	limit, offset := parsePagination(c)
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	workspaces, err := h.workspaceService.ListWorkspaces(ctx, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list workspaces", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list workspaces",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"workspaces": workspaces,
+		"limit":      limit,
+		"offset":     offset,
+		"count":      len(workspaces),
+	})
+}
+
+// GetWorkspace handles GET /api/workspaces/:id
+func (h *APIHandler) GetWorkspace(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	workspace, err := h.workspaceService.GetWorkspace(ctx, id)
+	if err != nil {
+		return h.workspaceError(c, err, "failed to get workspace")
+	}
+
+	return c.JSON(workspace)
+}
+
+// UpdateWorkspace handles PATCH /api/workspaces/:id
+func (h *APIHandler) UpdateWorkspace(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req WorkspaceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required",
+		})
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	workspace, err := h.workspaceService.RenameWorkspace(ctx, id, req.Name)
+	if err != nil {
+		return h.workspaceError(c, err, "failed to update workspace")
+	}
+
+	return c.JSON(workspace)
+}
+
+// DeleteWorkspace handles DELETE /api/workspaces/:id
+// Only workspaces without links (including trashed ones) can be deleted.
+func (h *APIHandler) DeleteWorkspace(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.workspaceService.DeleteWorkspace(ctx, id); err != nil {
+		return h.workspaceError(c, err, "failed to delete workspace")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListWorkspaceMembers handles GET /api/workspaces/:id/members
+func (h *APIHandler) ListWorkspaceMembers(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	members, err := h.workspaceService.ListMembers(ctx, id)
+	if err != nil {
+		return h.workspaceError(c, err, "failed to list workspace members")
+	}
+
+	return c.JSON(fiber.Map{
+		"members": members,
+		"count":   len(members),
+	})
+}
+
+// SetWorkspaceMember handles PUT /api/workspaces/:id/members/:member
+// Adds the member or changes their role (owner or member, default member).
+func (h *APIHandler) SetWorkspaceMember(c *fiber.Ctx) error {
+	id := c.Params("id")
+	memberID := strings.TrimSpace(c.Params("member"))
+	if memberID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "member is required",
+		})
+	}
+
+	var req WorkspaceMemberRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	member, err := h.workspaceService.SetMember(ctx, id, memberID, req.Role)
+	if err != nil {
+		return h.workspaceError(c, err, "failed to save workspace member")
+	}
+
+	return c.JSON(member)
+}
+
+// RemoveWorkspaceMember handles DELETE /api/workspaces/:id/members/:member
+func (h *APIHandler) RemoveWorkspaceMember(c *fiber.Ctx) error {
+	id := c.Params("id")
+	memberID := c.Params("member")
+
+	ctx := c.UserContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := h.workspaceService.RemoveMember(ctx, id, memberID); err != nil {
+		return h.workspaceError(c, err, "failed to remove workspace member")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// workspaceError maps workspace service errors onto HTTP responses.
+func (h *APIHandler) workspaceError(c *fiber.Ctx, err error, message string) error {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return respondValidationError(c, validationErr)
+	case errors.Is(err, repository.ErrWorkspaceNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "workspace not found",
+		})
+	case errors.Is(err, repository.ErrMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "workspace member not found",
+		})
+	case errors.Is(err, repository.ErrWorkspaceNotEmpty):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "workspace still owns links",
+		})
+	case errors.Is(err, service.ErrDefaultWorkspace):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	h.logger.Error(message, zap.Error(err), zap.String("workspace_id", c.Params("id")))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": message,
+	})
+}
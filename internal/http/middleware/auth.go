@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"go.uber.org/zap"
+)
+
+const apiKeyLocalsKey = "api_key"
+
+// APIKeyAuthenticator resolves a bearer token to an API key.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*model.APIKey, error)
+}
+
+// APIKeyAuth rejects requests that do not carry a valid "Authorization: Bearer <key>" header.
+// The authenticated key is available to later handlers through APIKeyFromContext.
+func APIKeyAuth(auth APIKeyAuthenticator, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodOptions {
+			return c.Next()
+		}
+
+		token, ok := bearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			return unauthorized(c, "missing bearer token")
+		}
+
+		ctx := c.UserContext()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		key, err := auth.Authenticate(ctx, token)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				return unauthorized(c, "invalid api key")
+			}
+			logger.Error("api key authentication failed", zap.Error(err))
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "authentication unavailable",
+			})
+		}
+
+		c.Locals(apiKeyLocalsKey, key)
+		return c.Next()
+	}
+}
+
+// RequireScope allows the request only when the authenticated key grants scope.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := APIKeyFromContext(c)
+		if key == nil {
+			return unauthorized(c, "missing bearer token")
+		}
+		if !key.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "api key lacks required scope " + scope,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// APIKeyFromContext returns the key authenticated by APIKeyAuth, or nil.
+func APIKeyFromContext(c *fiber.Ctx) *model.APIKey {
+	key, _ := c.Locals(apiKeyLocalsKey).(*model.APIKey)
+	return key
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(c *fiber.Ctx, message string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": message,
+	})
+}
@@ -4,13 +4,33 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// CORS returns a CORS middleware configuration
-func CORS() fiber.Handler {
+// CORS returns a CORS middleware configuration. Only the listed origins are
+// echoed back, "*" accepts any origin, and with no allowed origins no
+// cross-origin access is granted at all.
+func CORS(allowedOrigins ...string) fiber.Handler {
+	allowed := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = struct{}{}
+	}
+	_, anyOrigin := allowed["*"]
+
 	return func(c *fiber.Ctx) error {
-		c.Set("Access-Control-Allow-Origin", "*")
-		c.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
-		c.Set("Access-Control-Expose-Headers", "Content-Length, Content-Type")
+		if len(allowed) == 0 {
+			return c.Next()
+		}
+		if anyOrigin {
+			c.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Vary(fiber.HeaderOrigin)
+			if origin := c.Get(fiber.HeaderOrigin); origin != "" {
+				if _, ok := allowed[origin]; ok {
+					c.Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+		}
+		c.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Workspace-ID, If-Match, Idempotency-Key")
+		c.Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, ETag")
 		c.Set("Access-Control-Max-Age", "86400")
 
 		if c.Method() == "OPTIONS" {
@@ -19,4 +39,4 @@ func CORS() fiber.Handler {
 
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sifan077/PowerURL/internal/app/model"
+	"github.com/sifan077/PowerURL/internal/app/service"
+	"go.uber.org/zap"
+)
+
+// HeaderWorkspaceID selects the workspace a request acts in.
+const HeaderWorkspaceID = "X-Workspace-ID"
+
+// WorkspaceAccessChecker decides whether an API key may act in a workspace.
+type WorkspaceAccessChecker interface {
+	CanAccess(ctx context.Context, key *model.APIKey, workspaceID string) (bool, error)
+}
+
+// WorkspaceScope scopes the request to a workspace. It must run after APIKeyAuth.
+// Requests act in the key's own workspace unless X-Workspace-ID names another one
+// the key may access. Service calls then see the workspace through service.ActorFromContext.
+func WorkspaceScope(access WorkspaceAccessChecker, logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := APIKeyFromContext(c)
+		if key == nil {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		workspaceID := key.WorkspaceID
+		if workspaceID == "" {
+			workspaceID = model.DefaultWorkspaceID
+		}
+		if requested := strings.TrimSpace(c.Get(HeaderWorkspaceID)); requested != "" && requested != workspaceID {
+			allowed, err := access.CanAccess(ctx, key, requested)
+			if err != nil {
+				logger.Error("workspace access check failed", zap.Error(err), zap.String("workspace_id", requested))
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"error": "authorization unavailable",
+				})
+			}
+			if !allowed {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "api key cannot access workspace " + requested,
+				})
+			}
+			workspaceID = requested
+		}
+
+		ownerID := key.OwnerID
+		if ownerID == "" {
+			ownerID = "apikey:" + key.ID
+		}
+		c.SetUserContext(service.WithActor(ctx, service.Actor{WorkspaceID: workspaceID, OwnerID: ownerID}))
+		return c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor serialises v into an opaque, URL-safe pagination token.
+func EncodeCursor(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor reverses EncodeCursor into v.
+func DecodeCursor(token string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
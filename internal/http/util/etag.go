@@ -0,0 +1,39 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionETag renders a resource version as a strong entity tag.
+func VersionETag(version int) string {
+	return fmt.Sprintf(`"v%d"`, version)
+}
+
+// ParseIfMatch extracts the versions listed in an If-Match header built from
+// VersionETag values. It returns nil when the header is absent or "*", and an
+// empty, non-nil slice when no listed tag names a version (so nothing can match).
+func ParseIfMatch(header string) []int {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		// Weak tags never satisfy If-Match, which requires strong comparison.
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		tag = strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`)
+		if !strings.HasPrefix(tag, "v") {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1:]); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
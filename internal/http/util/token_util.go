@@ -132,6 +132,49 @@ func (s *TokenSigner) ValidateAndExtractClickID(code, token string) (string, err
 	return clickID, nil
 }
 
+// SignValue binds value to code with a signature that does not expire, for state
+// kept in cookies. Signed values are never accepted as tokens and vice versa.
+func (s *TokenSigner) SignValue(code, value string) (string, error) {
+	if len(s.secret) == 0 {
+		return "", ErrMissingSecret
+	}
+	sigEnc := base64.RawURLEncoding.EncodeToString(s.signValue(code, value)[:16])
+	return fmt.Sprintf("%s.%s", value, sigEnc), nil
+}
+
+// VerifyValue returns the value of a SignValue result for code.
+func (s *TokenSigner) VerifyValue(code, signed string) (string, error) {
+	if len(s.secret) == 0 {
+		return "", ErrMissingSecret
+	}
+
+	idx := strings.LastIndexByte(signed, '.')
+	if idx < 0 {
+		return "", ErrInvalidToken
+	}
+	value := signed[:idx]
+	sigProvided, err := base64.RawURLEncoding.DecodeString(signed[idx+1:])
+	if err != nil || len(sigProvided) != 16 {
+		return "", ErrInvalidToken
+	}
+	if !hmac.Equal(sigProvided, s.signValue(code, value)[:16]) {
+		return "", ErrInvalidToken
+	}
+	return value, nil
+}
+
+// signValue uses a key derived from the secret, so no value signature can pass
+// as the signature of a token payload.
+func (s *TokenSigner) signValue(code, value string) []byte {
+	derive := hmac.New(sha256.New, s.secret)
+	derive.Write([]byte("signed-value"))
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write([]byte(code))
+	mac.Write([]byte("|"))
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
 func (s *TokenSigner) sign(code string, payload []byte) []byte {
 	mac := hmac.New(sha256.New, s.secret)
 	mac.Write([]byte(code))
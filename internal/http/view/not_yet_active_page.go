@@ -0,0 +1,91 @@
+package view
+
+import (
+	"bytes"
+	"html/template"
+	"time"
+)
+
+// NotYetActivePageData provides the dynamic fields required by the not-yet-active template.
+type NotYetActivePageData struct {
+	Code     string
+	StartsAt time.Time
+}
+
+var notYetActivePageTmpl = template.Must(template.New("not_yet_active_page").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="viewport" content="width=device-width, initial-scale=1" />
+	<meta name="robots" content="noindex" />
+	<title>Not active yet</title>
+	<style>
+		:root {
+			--bg: #090a0f;
+			--card: rgba(255, 255, 255, 0.05);
+			--border: rgba(255, 255, 255, 0.15);
+			--text: #e7ecff;
+			--muted: #a1acc5;
+			font-family: "Inter", -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif;
+		}
+		* { box-sizing: border-box; }
+		body {
+			margin: 0;
+			min-height: 100vh;
+			display: flex;
+			align-items: center;
+			justify-content: center;
+			background: radial-gradient(circle at 20% 20%, #111827, #030712 60%);
+			color: var(--text);
+		}
+		.card {
+			background: var(--card);
+			border: 1px solid var(--border);
+			border-radius: 18px;
+			padding: 32px;
+			width: min(520px, 92vw);
+			box-shadow: 0 45px 100px rgba(0,0,0,0.35);
+			backdrop-filter: blur(18px);
+		}
+		h1 {
+			font-size: 1.5rem;
+			margin-bottom: 6px;
+		}
+		p {
+			color: var(--muted);
+			margin-top: 0;
+		}
+	</style>
+</head>
+<body>
+	<div class="card">
+		<h1>This link is not active yet</h1>
+		<p>Short link <strong>/{{.Code}}</strong> opens on
+			<time id="starts-at" datetime="{{.StartsAt.UTC.Format "2006-01-02T15:04:05Z07:00"}}">{{.StartsAt.UTC.Format "Jan 2, 2006 15:04 MST"}}</time>.
+		</p>
+		<p>This page reloads automatically once it does.</p>
+	</div>
+
+	<script>
+		(function() {
+			const el = document.getElementById("starts-at");
+			const startsAt = new Date(el.getAttribute("datetime"));
+			el.textContent = startsAt.toLocaleString();
+			const wait = startsAt.getTime() - Date.now();
+			// Browsers clamp long timeouts; re-check at most once a day.
+			setTimeout(() => window.location.reload(), Math.min(Math.max(wait, 0) + 1000, 86400000));
+		})();
+	</script>
+</body>
+</html>
+`))
+
+// RenderNotYetActivePage expands the not-yet-active template with the provided data.
+func RenderNotYetActivePage(data NotYetActivePageData) (string, error) {
+	var buf bytes.Buffer
+	if err := notYetActivePageTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,129 @@
+package view
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// PasswordPageData provides the dynamic fields required by the password template.
+type PasswordPageData struct {
+	Code string
+	// Action is the URL the password form posts to.
+	Action string
+	// RuleID is the routing rule matched when the form was first shown.
+	RuleID string
+	// Error is shown above the form after a rejected attempt.
+	Error string
+	// Warning tells visitors the destination has been reported as malicious.
+	Warning string
+}
+
+var passwordPageTmpl = template.Must(template.New("password_page").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="viewport" content="width=device-width, initial-scale=1" />
+	<meta name="robots" content="noindex" />
+	<title>Password required</title>
+	<style>
+		:root {
+			--bg: #090a0f;
+			--card: rgba(255, 255, 255, 0.05);
+			--border: rgba(255, 255, 255, 0.15);
+			--text: #e7ecff;
+			--muted: #a1acc5;
+			--accent: #7dd3fc;
+			--accent-strong: #38bdf8;
+			--error: #fca5a5;
+			font-family: "Inter", -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif;
+		}
+		* { box-sizing: border-box; }
+		body {
+			margin: 0;
+			min-height: 100vh;
+			display: flex;
+			align-items: center;
+			justify-content: center;
+			background: radial-gradient(circle at 20% 20%, #111827, #030712 60%);
+			color: var(--text);
+		}
+		.card {
+			background: var(--card);
+			border: 1px solid var(--border);
+			border-radius: 18px;
+			padding: 32px;
+			width: min(520px, 92vw);
+			box-shadow: 0 45px 100px rgba(0,0,0,0.35);
+			backdrop-filter: blur(18px);
+		}
+		h1 {
+			font-size: 1.5rem;
+			margin-bottom: 6px;
+		}
+		p {
+			color: var(--muted);
+			margin-top: 0;
+		}
+		.error {
+			color: var(--error);
+		}
+		form {
+			display: flex;
+			gap: 12px;
+			margin-top: 24px;
+			flex-wrap: wrap;
+		}
+		input[type="password"] {
+			flex: 1;
+			min-width: 200px;
+			height: 48px;
+			padding: 0 18px;
+			border-radius: 999px;
+			border: 1px solid var(--border);
+			background: rgba(255, 255, 255, 0.04);
+			color: var(--text);
+			font-size: 1rem;
+		}
+		button {
+			padding: 0 28px;
+			height: 48px;
+			border: 0;
+			border-radius: 999px;
+			background: linear-gradient(120deg, var(--accent), var(--accent-strong));
+			color: #050708;
+			font-weight: 600;
+			font-size: 1rem;
+			cursor: pointer;
+			transition: transform 0.15s ease, opacity 0.15s ease;
+		}
+		button:hover {
+			transform: translateY(-1px);
+			opacity: 0.92;
+		}
+	</style>
+</head>
+<body>
+	<div class="card">
+		<h1>Password required</h1>
+		<p>Short link <strong>/{{.Code}}</strong> is protected. Enter its password to continue.</p>
+		{{if .Warning}}<p class="error"><strong>Warning:</strong> {{.Warning}}</p>{{end}}
+		{{if .Error}}<p class="error" role="alert">{{.Error}}</p>{{end}}
+		<form method="post" action="{{.Action}}">
+			{{if .RuleID}}<input type="hidden" name="rule" value="{{.RuleID}}" />{{end}}
+			<input type="password" name="password" autocomplete="current-password" placeholder="Password" required autofocus />
+			<button type="submit">Continue</button>
+		</form>
+	</div>
+</body>
+</html>
+`))
+
+// RenderPasswordPage expands the password template with the provided data.
+func RenderPasswordPage(data PasswordPageData) (string, error) {
+	var buf bytes.Buffer
+	if err := passwordPageTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
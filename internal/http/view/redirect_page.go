@@ -15,6 +15,10 @@ type RedirectPageData struct {
 	TimerSeconds int
 	Token        string
 	ClickID      string
+	// PreviewTitle and FaviconURL describe the destination page when its preview
+	// has been fetched; the bare TargetURL is shown otherwise.
+	PreviewTitle string
+	FaviconURL   string
 }
 
 var redirectPageTmpl = template.Must(template.New("redirect_page").Parse(`
@@ -70,6 +74,23 @@ var redirectPageTmpl = template.Must(template.New("redirect_page").Parse(`
 			border: 1px solid rgba(125, 211, 252, 0.25);
 			word-break: break-all;
 		}
+		.destination-site {
+			display: flex;
+			align-items: center;
+			gap: 10px;
+			font-weight: 600;
+			word-break: normal;
+		}
+		.destination-site img {
+			width: 20px;
+			height: 20px;
+			flex-shrink: 0;
+		}
+		.destination-url {
+			margin-top: 6px;
+			font-size: 0.85rem;
+			color: var(--muted);
+		}
 		.destination-label {
 			font-size: 0.82rem;
 			text-transform: uppercase;
@@ -119,7 +140,15 @@ var redirectPageTmpl = template.Must(template.New("redirect_page").Parse(`
 
 		<div class="destination">
 			<div class="destination-label">Destination</div>
+			{{if .PreviewTitle}}
+			<div class="destination-site">
+				{{if .FaviconURL}}<img src="{{.FaviconURL}}" alt="" referrerpolicy="no-referrer" />{{end}}
+				<span>{{.PreviewTitle}}</span>
+			</div>
+			<div class="destination-url">{{.TargetURL}}</div>
+			{{else}}
 			<div>{{.TargetURL}}</div>
+			{{end}}
 		</div>
 
 		{{if eq .Mode "timer"}}
@@ -0,0 +1,44 @@
+package view
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// SocialCardData provides the OpenGraph and Twitter Card fields served to social crawlers.
+type SocialCardData struct {
+	URL         string // the short link itself
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+var socialCardTmpl = template.Must(template.New("social_card").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="robots" content="noindex" />
+	<title>{{.Title}}</title>
+	<meta property="og:type" content="website" />
+	<meta property="og:url" content="{{.URL}}" />
+	<meta property="og:title" content="{{.Title}}" />
+	{{if .Description}}<meta property="og:description" content="{{.Description}}" />
+	<meta name="description" content="{{.Description}}" />{{end}}
+	{{if .ImageURL}}<meta property="og:image" content="{{.ImageURL}}" />{{end}}
+	<meta name="twitter:card" content="{{if .ImageURL}}summary_large_image{{else}}summary{{end}}" />
+	<meta name="twitter:title" content="{{.Title}}" />
+	{{if .Description}}<meta name="twitter:description" content="{{.Description}}" />{{end}}
+	{{if .ImageURL}}<meta name="twitter:image" content="{{.ImageURL}}" />{{end}}
+</head>
+<body></body>
+</html>
+`))
+
+// RenderSocialCard expands the social card template with the provided data.
+func RenderSocialCard(data SocialCardData) (string, error) {
+	var buf bytes.Buffer
+	if err := socialCardTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
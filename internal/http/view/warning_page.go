@@ -0,0 +1,120 @@
+package view
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// WarningPageData provides the dynamic fields required by the warning template.
+type WarningPageData struct {
+	Code      string
+	TargetURL string
+	// ContinueURL lets the visitor proceed after all.
+	ContinueURL string
+}
+
+var warningPageTmpl = template.Must(template.New("warning_page").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="viewport" content="width=device-width, initial-scale=1" />
+	<meta name="robots" content="noindex" />
+	<title>Warning: suspicious destination</title>
+	<style>
+		:root {
+			--bg: #090a0f;
+			--card: rgba(255, 255, 255, 0.05);
+			--border: rgba(255, 255, 255, 0.15);
+			--text: #e7ecff;
+			--muted: #a1acc5;
+			--danger: #f87171;
+			--danger-strong: #ef4444;
+			font-family: "Inter", -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif;
+		}
+		* { box-sizing: border-box; }
+		body {
+			margin: 0;
+			min-height: 100vh;
+			display: flex;
+			align-items: center;
+			justify-content: center;
+			background: radial-gradient(circle at 20% 20%, #1f1111, #030712 60%);
+			color: var(--text);
+		}
+		.card {
+			background: var(--card);
+			border: 1px solid rgba(248, 113, 113, 0.35);
+			border-radius: 18px;
+			padding: 32px;
+			width: min(520px, 92vw);
+			box-shadow: 0 45px 100px rgba(0,0,0,0.35);
+			backdrop-filter: blur(18px);
+		}
+		h1 {
+			font-size: 1.5rem;
+			margin-bottom: 6px;
+			color: var(--danger);
+		}
+		p {
+			color: var(--muted);
+			margin-top: 0;
+		}
+		.destination {
+			margin: 24px 0;
+			padding: 18px;
+			border-radius: 14px;
+			background: rgba(248, 113, 113, 0.07);
+			border: 1px solid rgba(248, 113, 113, 0.25);
+			word-break: break-all;
+		}
+		.destination-label {
+			font-size: 0.82rem;
+			text-transform: uppercase;
+			letter-spacing: 0.08em;
+			color: var(--muted);
+			margin-bottom: 8px;
+		}
+		.actions {
+			display: flex;
+			align-items: center;
+			gap: 12px;
+			margin-top: 24px;
+			flex-wrap: wrap;
+		}
+		a.proceed {
+			color: var(--muted);
+			font-size: 0.9rem;
+		}
+		a.proceed:hover {
+			color: var(--danger-strong);
+		}
+	</style>
+</head>
+<body>
+	<div class="card">
+		<h1>This link may be unsafe</h1>
+		<p>Short link <strong>/{{.Code}}</strong> leads to a site that has been reported as malicious. It may try to steal your passwords or install harmful software.</p>
+
+		<div class="destination">
+			<div class="destination-label">Destination</div>
+			<div>{{.TargetURL}}</div>
+		</div>
+
+		<p>We recommend closing this page.</p>
+		<div class="actions">
+			<a class="proceed" href="{{.ContinueURL}}" rel="nofollow noreferrer">I understand the risk, continue anyway</a>
+		</div>
+	</div>
+</body>
+</html>
+`))
+
+// RenderWarningPage expands the warning template with the provided data.
+func RenderWarningPage(data WarningPageData) (string, error) {
+	var buf bytes.Buffer
+	if err := warningPageTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,144 @@
+package datacenter
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/infra/filewatch"
+	"go.uber.org/zap"
+)
+
+// prefixes maps masked network prefixes to the name of the list they came from,
+// with the distinct prefix lengths kept for lookups.
+type prefixes struct {
+	networks map[netip.Prefix]string
+	bits     []int
+}
+
+// Ranges matches IP addresses against local lists of hosting and cloud networks
+// and reloads them when any of the files changes on disk.
+//
+// Each line of a list is a CIDR prefix ("203.0.113.0/24") or a single address;
+// anything after "#" is a comment.
+type Ranges struct {
+	watcher *filewatch.Watcher
+
+	mu       sync.RWMutex
+	prefixes prefixes
+}
+
+// Open loads the datacenter lists configured in cfg and starts watching them for changes.
+func Open(cfg config.BotDetectionConfig, logger *zap.Logger) (*Ranges, error) {
+	if len(cfg.DatacenterFiles) == 0 {
+		return nil, errors.New("datacenter files are not configured")
+	}
+
+	r := &Ranges{}
+	watcher, err := filewatch.Start("datacenter lists", cfg.DatacenterFiles, cfg.ReloadInterval, logger, r.load)
+	if err != nil {
+		return nil, err
+	}
+	r.watcher = watcher
+	return r, nil
+}
+
+// Match returns why ip is listed, e.g. `network 203.0.113.0/24 listed in aws.txt`,
+// or "" when it is not.
+func (r *Ranges) Match(ip string) string {
+	if r == nil {
+		return ""
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, bits := range r.prefixes.bits {
+		network, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if source, ok := r.prefixes.networks[network]; ok {
+			return fmt.Sprintf("network %s listed in %s", network, source)
+		}
+	}
+	return ""
+}
+
+// Size returns the number of listed networks.
+func (r *Ranges) Size() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.prefixes.networks)
+}
+
+// Close stops watching the files.
+func (r *Ranges) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.watcher.Close()
+}
+
+// load parses all lists and swaps them in.
+func (r *Ranges) load(paths []string) error {
+	loaded := prefixes{networks: make(map[netip.Prefix]string)}
+	for _, path := range paths {
+		if err := filewatch.ReadLines(path, loaded.addLine); err != nil {
+			return fmt.Errorf("datacenter lists: %w", err)
+		}
+	}
+	for network := range loaded.networks {
+		if !slices.Contains(loaded.bits, network.Bits()) {
+			loaded.bits = append(loaded.bits, network.Bits())
+		}
+	}
+	// Prefer the most specific network, so the reported source is the narrowest match.
+	slices.SortFunc(loaded.bits, func(a, b int) int { return b - a })
+
+	r.mu.Lock()
+	r.prefixes = loaded
+	r.mu.Unlock()
+	return nil
+}
+
+func (p *prefixes) addLine(line, source string) {
+	line, _, _ = strings.Cut(line, "#")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+
+	entry := fields[0]
+	if !strings.Contains(entry, "/") {
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return
+		}
+		addr = addr.Unmap()
+		entry = netip.PrefixFrom(addr, addr.BitLen()).String()
+	}
+	network, err := netip.ParsePrefix(entry)
+	if err != nil {
+		return
+	}
+	if network.Addr().Is4In6() {
+		if network.Bits() < 96 {
+			return
+		}
+		network = netip.PrefixFrom(network.Addr().Unmap(), network.Bits()-96)
+	}
+	p.networks[network.Masked()] = source
+}
@@ -0,0 +1,85 @@
+package datacenter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+func TestRanges_Match(t *testing.T) {
+	dir := t.TempDir()
+	aws := filepath.Join(dir, "aws.txt")
+	hosting := filepath.Join(dir, "hosting.txt")
+	writeList(t, aws,
+		"# AWS ranges",
+		"203.0.113.0/24",
+		"198.51.100.7   # single address",
+		"2001:db8:aa::/48",
+		"not a network",
+		"192.0.2.300/24",
+	)
+	writeList(t, hosting,
+		"203.0.113.128/25",
+		"::ffff:192.0.2.0/120",
+		"::ffff:0:0/64",
+		"10.1.2.99/16",
+	)
+
+	ranges, err := Open(config.BotDetectionConfig{DatacenterFiles: []string{aws, hosting}}, nil)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer ranges.Close()
+
+	if got := ranges.Size(); got != 6 {
+		t.Fatalf("expected 6 networks, got %d", got)
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.5", "network 203.0.113.0/24 listed in aws.txt"},
+		{"203.0.113.200", "network 203.0.113.128/25 listed in hosting.txt"},
+		{"198.51.100.7", "network 198.51.100.7/32 listed in aws.txt"},
+		{"198.51.100.8", ""},
+		{"2001:db8:aa:1::1", "network 2001:db8:aa::/48 listed in aws.txt"},
+		{"2001:db8:ab::1", ""},
+		{"192.0.2.10", "network 192.0.2.0/24 listed in hosting.txt"},
+		{"::ffff:203.0.113.5", "network 203.0.113.0/24 listed in aws.txt"},
+		{"::ffff:192.0.2.10", "network 192.0.2.0/24 listed in hosting.txt"},
+		{" 10.1.200.1 ", "network 10.1.0.0/16 listed in hosting.txt"},
+		{"192.0.3.1", ""},
+		{"not-an-ip", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ranges.Match(tt.ip); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+
+	var missing *Ranges
+	if missing.Match("203.0.113.5") != "" || missing.Size() != 0 || missing.Close() != nil {
+		t.Fatal("expected a nil Ranges to match nothing")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	if _, err := Open(config.BotDetectionConfig{}, nil); err == nil {
+		t.Fatal("expected missing files to be rejected")
+	}
+	if _, err := Open(config.BotDetectionConfig{DatacenterFiles: []string{filepath.Join(t.TempDir(), "missing.txt")}}, nil); err == nil {
+		t.Fatal("expected a missing file to be rejected")
+	}
+}
+
+func writeList(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package filewatch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultReloadInterval = time.Minute
+
+// LoadFunc reads paths and swaps what they hold in for the previously loaded data.
+// Calls never overlap.
+type LoadFunc func(paths []string) error
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher loads a set of files and loads them again whenever the size or
+// modification time of any of them changes, so they can be updated without a
+// restart. A failed reload keeps the previously loaded data.
+type Watcher struct {
+	name     string
+	paths    []string
+	interval time.Duration
+	load     LoadFunc
+	logger   *zap.Logger
+
+	files map[string]fileState // only touched by Start and then the watch goroutine
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Start loads paths with load and starts watching them for changes. name says what
+// the files hold in errors and logs, e.g. "threat feed"; reloadInterval is a
+// duration string and defaults to a minute.
+func Start(name string, paths []string, reloadInterval string, logger *zap.Logger, load LoadFunc) (*Watcher, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	interval := defaultReloadInterval
+	if reloadInterval != "" {
+		parsed, err := time.ParseDuration(reloadInterval)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s reload interval %q", name, reloadInterval)
+		}
+		interval = parsed
+	}
+
+	w := &Watcher{
+		name:     name,
+		paths:    paths,
+		interval: interval,
+		load:     load,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// Close stops watching the files.
+func (w *Watcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) watch() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := w.reload()
+			if err != nil {
+				w.logger.Error("failed to reload "+w.name+", keeping the loaded data", zap.Strings("paths", w.paths), zap.Error(err))
+				continue
+			}
+			if reloaded {
+				w.logger.Info("reloaded "+w.name, zap.Strings("paths", w.paths))
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// reload loads all files again when the size or modification time of any of them changed.
+func (w *Watcher) reload() (bool, error) {
+	files := make(map[string]fileState, len(w.paths))
+	for _, path := range w.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, fmt.Errorf("stat %s: %w", w.name, err)
+		}
+		files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	if w.files != nil && sameFiles(w.files, files) {
+		return false, nil
+	}
+
+	if err := w.load(w.paths); err != nil {
+		return false, err
+	}
+	w.files = files
+	return true, nil
+}
+
+func sameFiles(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		other, ok := b[path]
+		if !ok || !state.modTime.Equal(other.modTime) || state.size != other.size {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadLines calls add with every line of the text file at path and the file's base
+// name, which list formats report as the source of an entry.
+func ReadLines(path string, add func(line, source string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open list: %w", err)
+	}
+	defer file.Close()
+
+	source := filepath.Base(path)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		add(scanner.Text(), source)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read list %s: %w", source, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package filewatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var loaded []string
+	fail := false
+	load := func(paths []string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return errors.New("broken list")
+		}
+		var lines []string
+		for _, path := range paths {
+			if err := ReadLines(path, func(line, source string) { lines = append(lines, source+":"+line) }); err != nil {
+				return err
+			}
+		}
+		loaded = lines
+		return nil
+	}
+	current := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(loaded, ",")
+	}
+
+	w, err := Start("test list", []string{path}, "10ms", nil, load)
+	if err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	defer w.Close()
+	if got := current(); got != "list.txt:one" {
+		t.Fatalf("expected the initial load, got %q", got)
+	}
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := current(); got != "list.txt:one" {
+		t.Fatalf("expected a failed reload to keep the loaded data, got %q", got)
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	waitFor(t, func() bool { return current() == "list.txt:one,list.txt:two" })
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close error: %v", err)
+	}
+}
+
+func TestStart_Errors(t *testing.T) {
+	load := func(paths []string) error { return nil }
+	if _, err := Start("test list", []string{filepath.Join(t.TempDir(), "missing.txt")}, "", nil, load); err == nil {
+		t.Fatal("expected a missing file to be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Start("test list", []string{path}, "soon", nil, load); err == nil || !strings.Contains(err.Error(), "test list") {
+		t.Fatalf("expected an invalid reload interval error naming the list, got %v", err)
+	}
+	if _, err := Start("test list", []string{path}, "", nil, func(paths []string) error { return errors.New("bad") }); err == nil {
+		t.Fatal("expected the initial load error")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
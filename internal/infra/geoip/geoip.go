@@ -0,0 +1,121 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/infra/filewatch"
+	"go.uber.org/zap"
+)
+
+// Location is the geographic origin of an IP address.
+type Location struct {
+	Country string // ISO 3166-1 alpha-2, e.g. "DE"
+	Region  string // ISO 3166-2 subdivision, e.g. "DE-BY"; empty for country databases
+}
+
+// record mirrors the fields shared by GeoLite2/GeoIP2 country and city databases.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+// Database resolves IP addresses against a local .mmdb file and reloads it when
+// the file changes on disk, so it can be updated without a restart.
+type Database struct {
+	watcher *filewatch.Watcher
+	logger  *zap.Logger
+
+	mu     sync.RWMutex
+	reader *maxminddb.Reader
+}
+
+// Open loads the database configured in cfg and starts watching it for changes.
+func Open(cfg config.GeoIPConfig, logger *zap.Logger) (*Database, error) {
+	if cfg.DatabaseFile == "" {
+		return nil, errors.New("geoip database file is not configured")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	db := &Database{logger: logger}
+	watcher, err := filewatch.Start("geoip database", []string{cfg.DatabaseFile}, cfg.ReloadInterval, logger, db.load)
+	if err != nil {
+		return nil, err
+	}
+	db.watcher = watcher
+	return db, nil
+}
+
+// Lookup returns the location of ip. Unknown, private and malformed addresses
+// yield an empty Location.
+func (d *Database) Lookup(ip string) Location {
+	parsed := net.ParseIP(ip)
+	if d == nil || parsed == nil {
+		return Location{}
+	}
+
+	var rec record
+	d.mu.RLock()
+	err := d.reader.Lookup(parsed, &rec)
+	d.mu.RUnlock()
+	if err != nil {
+		d.logger.Debug("geoip lookup failed", zap.String("ip", ip), zap.Error(err))
+		return Location{}
+	}
+
+	loc := Location{Country: rec.Country.ISOCode}
+	if loc.Country != "" && len(rec.Subdivisions) > 0 && rec.Subdivisions[0].ISOCode != "" {
+		loc.Region = loc.Country + "-" + rec.Subdivisions[0].ISOCode
+	}
+	return loc
+}
+
+// Close stops watching the file and releases the database.
+func (d *Database) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.watcher.Close()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reader.Close()
+}
+
+// load opens the database file and swaps it in for the loaded one.
+func (d *Database) load(paths []string) error {
+	// Read into memory instead of mmap-ing, so a file overwritten in place cannot
+	// change underneath the loaded reader.
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		return fmt.Errorf("read geoip database: %w", err)
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("open geoip database: %w", err)
+	}
+
+	// Taking the write lock waits for in-flight lookups on the old reader.
+	d.mu.Lock()
+	old := d.reader
+	d.reader = reader
+	d.mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil {
+			d.logger.Warn("failed to close previous geoip database", zap.Error(err))
+		}
+	}
+	return nil
+}
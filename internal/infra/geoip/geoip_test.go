@@ -0,0 +1,153 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+func TestDatabase_LookupAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+	writeDatabase(t, path, "DE", "BY")
+
+	db, err := Open(config.GeoIPConfig{DatabaseFile: path, ReloadInterval: "10ms"}, nil)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"1.2.3.4", Location{Country: "DE", Region: "DE-BY"}},
+		{"1.255.0.1", Location{Country: "DE", Region: "DE-BY"}},
+		{"2.2.2.2", Location{}},
+		{"2001:db8::1", Location{}}, // IPv4-only database
+		{"not-an-ip", Location{}},
+	}
+	for _, tt := range tests {
+		if got := db.Lookup(tt.ip); got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+
+	// A country database has no subdivisions, so there is no region.
+	writeDatabase(t, path, "FR", "")
+	waitForLocation(t, db, "1.2.3.4", Location{Country: "FR"})
+
+	// A broken file keeps the loaded database.
+	if err := os.WriteFile(path, []byte("not a MaxMind DB"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := db.Lookup("1.2.3.4"); got != (Location{Country: "FR"}) {
+		t.Fatalf("expected the loaded database to stay in use, got %+v", got)
+	}
+
+	var missing *Database
+	if missing.Lookup("1.2.3.4") != (Location{}) || missing.Close() != nil {
+		t.Fatal("expected a nil Database to resolve nothing")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	if _, err := Open(config.GeoIPConfig{}, nil); err == nil {
+		t.Fatal("expected a missing database file to be rejected")
+	}
+
+	dir := t.TempDir()
+	if _, err := Open(config.GeoIPConfig{DatabaseFile: filepath.Join(dir, "missing.mmdb")}, nil); err == nil {
+		t.Fatal("expected a nonexistent file to be rejected")
+	}
+
+	broken := filepath.Join(dir, "broken.mmdb")
+	if err := os.WriteFile(broken, []byte("not a MaxMind DB"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(config.GeoIPConfig{DatabaseFile: broken}, nil); err == nil {
+		t.Fatal("expected an invalid database to be rejected")
+	}
+
+	valid := filepath.Join(dir, "valid.mmdb")
+	writeDatabase(t, valid, "DE", "")
+	if _, err := Open(config.GeoIPConfig{DatabaseFile: valid, ReloadInterval: "-1s"}, nil); err == nil {
+		t.Fatal("expected an invalid reload interval to be rejected")
+	}
+}
+
+func waitForLocation(t *testing.T, db *Database, ip string, want Location) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for db.Lookup(ip) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Lookup(%q) = %+v, want %+v after a reload", ip, db.Lookup(ip), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// writeDatabase writes a minimal IPv4 MaxMind DB that maps 1.0.0.0/8 to country
+// and, unless it is empty, subdivision.
+func writeDatabase(t *testing.T, path, country, subdivision string) {
+	t.Helper()
+
+	// Search tree of 24-bit records: nodes 0-6 follow the zero bits of 1.0.0.0/8 to
+	// node 7, whose one branch points at the only record of the data section.
+	const nodeCount = 8
+	var buf []byte
+	for i := 0; i < nodeCount; i++ {
+		left, right := uint32(i+1), uint32(nodeCount)
+		if i == nodeCount-1 {
+			left, right = nodeCount, nodeCount+16
+		}
+		buf = append(buf, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+	}
+	buf = append(buf, make([]byte, 16)...)
+
+	record := map[string][]byte{"country": mmdbMap(map[string][]byte{"iso_code": mmdbString(country)})}
+	if subdivision != "" {
+		record["subdivisions"] = mmdbArray(mmdbMap(map[string][]byte{"iso_code": mmdbString(subdivision)}))
+	}
+	buf = append(buf, mmdbMap(record)...)
+
+	buf = append(buf, "\xab\xcd\xefMaxMind.com"...)
+	buf = append(buf, mmdbMap(map[string][]byte{
+		"node_count":                  {0xc1, nodeCount},
+		"record_size":                 {0xa1, 24},
+		"ip_version":                  {0xa1, 4},
+		"binary_format_major_version": {0xa1, 2},
+		"database_type":               mmdbString("Test"),
+	})...)
+
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mmdbString encodes a UTF-8 string shorter than 29 bytes.
+func mmdbString(s string) []byte {
+	return append([]byte{0x40 | byte(len(s))}, s...)
+}
+
+// mmdbMap encodes a map with fewer than 29 entries.
+func mmdbMap(entries map[string][]byte) []byte {
+	buf := []byte{0xe0 | byte(len(entries))}
+	for key, value := range entries {
+		buf = append(buf, mmdbString(key)...)
+		buf = append(buf, value...)
+	}
+	return buf
+}
+
+// mmdbArray encodes an array, an extended type, with fewer than 29 elements.
+func mmdbArray(elements ...[]byte) []byte {
+	buf := []byte{byte(len(elements)), 11 - 7}
+	for _, element := range elements {
+		buf = append(buf, element...)
+	}
+	return buf
+}
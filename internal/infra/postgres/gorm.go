@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sifan077/PowerURL/config"
@@ -17,6 +18,7 @@ func NewGorm(cfg config.PostgresConfig) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger:                                   logger.Default.LogMode(logger.Warn),
 		DisableForeignKeyConstraintWhenMigrating: true,
+		TranslateError:                           true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("postgres: open gorm connection: %w", err)
@@ -44,3 +46,42 @@ func AutoMigrate(ctx context.Context, db *gorm.DB, models ...interface{}) error
 
 	return nil
 }
+
+// EnsureSequence creates the named sequence when it does not exist yet.
+func EnsureSequence(ctx context.Context, db *gorm.DB, name string) error {
+	if db == nil || name == "" {
+		return nil
+	}
+
+	stmt := fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s", pgQuoteIdent(name))
+	if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+		return fmt.Errorf("postgres: ensure sequence %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// EnsureTrigramIndex enables pg_trgm and creates a GIN trigram index on table.column
+// so ILIKE '%term%' searches can use an index.
+func EnsureTrigramIndex(ctx context.Context, db *gorm.DB, table, column string) error {
+	if db == nil {
+		return nil
+	}
+
+	if err := db.WithContext(ctx).Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return fmt.Errorf("postgres: enable pg_trgm: %w", err)
+	}
+
+	index := fmt.Sprintf("idx_%s_%s_trgm", table, column)
+	stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING gin (%s gin_trgm_ops)",
+		pgQuoteIdent(index), pgQuoteIdent(table), pgQuoteIdent(column))
+	if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+		return fmt.Errorf("postgres: create trigram index %s: %w", index, err)
+	}
+
+	return nil
+}
+
+func pgQuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
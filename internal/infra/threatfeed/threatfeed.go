@@ -0,0 +1,192 @@
+package threatfeed
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sifan077/PowerURL/config"
+	"github.com/sifan077/PowerURL/internal/infra/filewatch"
+	"go.uber.org/zap"
+)
+
+// hostsFileNames are the local names found in stock hosts files, which are never listed.
+var hostsFileNames = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"0.0.0.0":               true,
+}
+
+// entries maps normalized domains and URLs to the name of the list they came from.
+type entries struct {
+	domains map[string]string
+	urls    map[string]string
+}
+
+// Feed matches URLs against local blocklists and reloads them when any of the
+// files changes on disk, so they can be updated without a restart.
+//
+// Each line of a list is either a domain ("evil.example", which also covers its
+// subdomains), a URL ("http://evil.example/payload.exe", matched exactly, scheme
+// aside) or a hosts-file entry ("0.0.0.0 evil.example"). Lines starting with "#"
+// or "!" are comments.
+type Feed struct {
+	watcher *filewatch.Watcher
+
+	mu      sync.RWMutex
+	entries entries
+}
+
+// Open loads the lists configured in cfg and starts watching them for changes.
+func Open(cfg config.ThreatFeedConfig, logger *zap.Logger) (*Feed, error) {
+	if len(cfg.Files) == 0 {
+		return nil, errors.New("threat feed files are not configured")
+	}
+
+	f := &Feed{}
+	watcher, err := filewatch.Start("threat feed", cfg.Files, cfg.ReloadInterval, logger, f.load)
+	if err != nil {
+		return nil, err
+	}
+	f.watcher = watcher
+	return f, nil
+}
+
+// Match returns why raw is listed, e.g. `domain "evil.example" listed in urlhaus.txt`,
+// or "" when it is not.
+func (f *Feed) Match(raw string) string {
+	if f == nil {
+		return ""
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return ""
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key := urlKey(host, u)
+	if source, ok := f.entries.urls[key]; ok {
+		return fmt.Sprintf("URL %q listed in %s", key, source)
+	}
+	if trimmed, _, found := strings.Cut(key, "?"); found {
+		if source, ok := f.entries.urls[trimmed]; ok {
+			return fmt.Sprintf("URL %q listed in %s", trimmed, source)
+		}
+	}
+	for domain := host; domain != ""; {
+		if source, ok := f.entries.domains[domain]; ok {
+			return fmt.Sprintf("domain %q listed in %s", domain, source)
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return ""
+}
+
+// Size returns the number of listed domains and URLs.
+func (f *Feed) Size() (domains, urls int) {
+	if f == nil {
+		return 0, 0
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.entries.domains), len(f.entries.urls)
+}
+
+// Close stops watching the files.
+func (f *Feed) Close() error {
+	if f == nil {
+		return nil
+	}
+	return f.watcher.Close()
+}
+
+// load parses all lists and swaps them in.
+func (f *Feed) load(paths []string) error {
+	loaded := entries{domains: make(map[string]string), urls: make(map[string]string)}
+	for _, path := range paths {
+		if err := filewatch.ReadLines(path, loaded.addLine); err != nil {
+			return fmt.Errorf("threat feed: %w", err)
+		}
+	}
+
+	f.mu.Lock()
+	f.entries = loaded
+	f.mu.Unlock()
+	return nil
+}
+
+func (e entries) addLine(line, source string) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' || line[0] == '!' {
+		return
+	}
+	// Hosts files allow trailing comments; a "#" inside a URL is a fragment and has
+	// no whitespace in front of it.
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = line[:i]
+	}
+	if i := strings.Index(line, "\t#"); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+		for _, name := range fields[1:] {
+			if host := normalizeHost(name); host != "" && !hostsFileNames[host] {
+				e.domains[host] = source
+			}
+		}
+		return
+	}
+
+	entry := fields[0]
+	if !strings.Contains(entry, "/") {
+		if host := normalizeHost(strings.TrimPrefix(entry, "*.")); host != "" {
+			e.domains[host] = source
+		}
+		return
+	}
+	if !strings.Contains(entry, "://") {
+		entry = "http://" + entry
+	}
+	u, err := url.Parse(entry)
+	if err != nil {
+		return
+	}
+	if host := normalizeHost(u.Hostname()); host != "" {
+		e.urls[urlKey(host, u)] = source
+	}
+}
+
+// urlKey identifies a URL regardless of scheme, fragment and a trailing slash.
+func urlKey(host string, u *url.URL) string {
+	key := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
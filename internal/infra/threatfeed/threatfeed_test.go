@@ -0,0 +1,94 @@
+package threatfeed
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sifan077/PowerURL/config"
+)
+
+func TestFeed_Match(t *testing.T) {
+	dir := t.TempDir()
+	hosts := filepath.Join(dir, "hosts.txt")
+	urlhaus := filepath.Join(dir, "urlhaus.txt")
+	writeList(t, hosts,
+		"# stock entries",
+		"127.0.0.1 localhost localhost.localdomain",
+		"0.0.0.0 0.0.0.0",
+		"0.0.0.0 ads.example tracker.example   # two names on one line",
+		"0.0.0.0\tphish.example\t# tab before the comment",
+		"! adblock-style comment",
+		"*.wild.example",
+		"Evil.Example.",
+	)
+	writeList(t, urlhaus,
+		"http://malware.example/payload.exe",
+		"drop.example/files/stage2.bin",
+		"https://query.example/get?id=7",
+		"https://frag.example/page#section",
+		"https://slash.example/dir/",
+	)
+
+	feed, err := Open(config.ThreatFeedConfig{Files: []string{hosts, urlhaus}}, nil)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer feed.Close()
+
+	domains, urls := feed.Size()
+	if domains != 5 || urls != 5 {
+		t.Fatalf("expected 5 domains and 5 URLs, got %d and %d", domains, urls)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://ads.example/banner", `domain "ads.example" listed in hosts.txt`},
+		{"https://tracker.example", `domain "tracker.example" listed in hosts.txt`},
+		{"https://phish.example/login", `domain "phish.example" listed in hosts.txt`},
+		{"https://cdn.wild.example/x", `domain "wild.example" listed in hosts.txt`},
+		{"https://wild.example", `domain "wild.example" listed in hosts.txt`},
+		{"https://a.b.EVIL.example./", `domain "evil.example" listed in hosts.txt`},
+		{"https://localhost/", ""},
+		{"https://notevil.example/", ""},
+		{"https://malware.example/payload.exe", `URL "malware.example/payload.exe" listed in urlhaus.txt`},
+		{"https://malware.example/other.exe", ""},
+		{"http://drop.example/files/stage2.bin", `URL "drop.example/files/stage2.bin" listed in urlhaus.txt`},
+		{"https://drop.example/files/stage2.bin?utm_source=mail", `URL "drop.example/files/stage2.bin" listed in urlhaus.txt`},
+		{"https://query.example/get?id=7", `URL "query.example/get?id=7" listed in urlhaus.txt`},
+		{"https://query.example/get?id=8", ""},
+		{"https://query.example/get", ""},
+		{"https://frag.example/page", `URL "frag.example/page" listed in urlhaus.txt`},
+		{"https://slash.example/dir", `URL "slash.example/dir" listed in urlhaus.txt`},
+		{"not a url at all", ""},
+	}
+	for _, tt := range tests {
+		if got := feed.Match(tt.url); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	var missing *Feed
+	if missing.Match("https://ads.example") != "" || missing.Close() != nil {
+		t.Fatal("expected a nil Feed to match nothing")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	if _, err := Open(config.ThreatFeedConfig{}, nil); err == nil {
+		t.Fatal("expected missing files to be rejected")
+	}
+	if _, err := Open(config.ThreatFeedConfig{Files: []string{filepath.Join(t.TempDir(), "missing.txt")}}, nil); err == nil {
+		t.Fatal("expected a missing file to be rejected")
+	}
+}
+
+func writeList(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}